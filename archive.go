@@ -0,0 +1,207 @@
+// archive.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The separator between the path of an archive and the path of a member in it.
+const archiveSep = "!"
+
+// Magic numbers of archive and compression formats.
+var (
+	zipMagic   = []byte("PK\x03\x04")
+	gzipMagic  = []byte("\x1f\x8b")
+	bzip2Magic = []byte("BZh")
+	xzMagic    = []byte("\xfd7zXZ\x00")
+	tarMagic   = []byte("ustar") // At the offset 257
+)
+
+// unpack() reports whether the node describes a tar (optionally gzip/bzip2-
+// compressed) or zip archive, and stores its members to the members field.
+// Digests of members are computed by the h hash.Hash when reading the archive,
+// because members of a compressed archive can only be read sequentially. If
+// the archive is corrupted, the error will be stored to the err field of the
+// current node and members read before will still be stored. Tar archives
+// compressed by xz are not supported, they're reported as errors.
+func (n *node) unpack(exit trigger, h hash.Hash) bool {
+	if n.container != "" || n.path == "" {
+		return false // Nested archives are not unpacked.
+	}
+
+	f, err := os.Open(n.path)
+	if err != nil {
+		return false // The digester will report the error.
+	}
+	defer f.Close()
+
+	var (
+		br    = bufio.NewReader(f)
+		magic []byte
+		ns    []*node
+	)
+
+	if magic, err = br.Peek(len(xzMagic)); err != nil {
+		return false
+	}
+
+	switch {
+	case bytes.Equal(magic, xzMagic):
+		if name := strings.ToLower(n.path); strings.HasSuffix(name, ".tar.xz") || strings.HasSuffix(name, ".txz") {
+			n.err = errorf("xz-compressed archives are not supported")
+		}
+		return false
+	case bytes.HasPrefix(magic, zipMagic):
+		ns, err = n.unzip(exit, f, h)
+	case bytes.HasPrefix(magic, gzipMagic):
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(br); err != nil {
+			return false
+		}
+		ns, err = n.untar(exit, bufio.NewReader(gz), h)
+	case bytes.HasPrefix(magic, bzip2Magic):
+		ns, err = n.untar(exit, bufio.NewReader(bzip2.NewReader(br)), h)
+	default:
+		ns, err = n.untar(exit, br, h)
+	}
+
+	if ns == nil && err == errNotArchive {
+		return false
+	}
+
+	n.packed, n.err = true, err
+	sort.Slice(ns, func(i, j int) bool { return ns[i].path < ns[j].path })
+	n.members = ns
+	return true
+}
+
+// The error returned when the file is not an archive.
+var errNotArchive = errorf("not an archive")
+
+// untar() reads members of a tar archive. A hard link shares the digest of the
+// earlier member it links to.
+func (n *node) untar(exit trigger, br *bufio.Reader, h hash.Hash) ([]*node, error) {
+	header, err := br.Peek(257 + len(tarMagic))
+	if err != nil || !bytes.Equal(header[257:], tarMagic) {
+		return nil, errNotArchive
+	}
+
+	var (
+		tr    = tar.NewReader(br)
+		ns    []*node
+		links = make(map[string]*node) // Regular members which can be linked to.
+	)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return ns, nil
+		} else if err != nil {
+			return ns, err
+		}
+
+		if m := n.member(hdr.Name, hdr.FileInfo()); m != nil {
+			switch start := time.Now(); {
+			case hdr.Typeflag == tar.TypeLink:
+				if target := links[path.Clean("/"+hdr.Linkname)]; target != nil {
+					m.link, m.sum, m.err, m.codec = target, target.sum, target.err, target.codec
+				} else {
+					m.err = errorf("hard link target '%s' not found", hdr.Linkname)
+				}
+				m.settle(start)
+			case m.isregular():
+				m.source = func() (io.ReadCloser, error) { return ioutil.NopCloser(tr), nil }
+				if m.hash(exit, h); m.err == errInterrupted {
+					return ns, nil
+				}
+			}
+
+			if m.isregular() {
+				links[path.Clean("/"+hdr.Name)] = m
+			}
+			ns = append(ns, m)
+		}
+	}
+}
+
+// unzip() reads members of a zip archive.
+func (n *node) unzip(exit trigger, f *os.File, h hash.Hash) ([]*node, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zip.NewReader(f, fi.Size())
+	if err != nil {
+		return nil, errNotArchive
+	}
+
+	var ns []*node
+	for _, zf := range zr.File {
+		if m := n.member(zf.Name, zf.FileInfo()); m != nil {
+			if m.isregular() {
+				m.source = zf.Open
+				if m.hash(exit, h); m.err == errInterrupted {
+					return ns, nil
+				}
+			}
+			ns = append(ns, m)
+		}
+	}
+	return ns, nil
+}
+
+// member() creates a node of a member in the archive, which shares the walk
+// sequence of the archive. Directories will be ignored, because members are
+// flattened. Hidden members will be ignored too unless the all option is
+// specified.
+func (n *node) member(name string, fi os.FileInfo) *node {
+	if fi.IsDir() {
+		return nil
+	}
+
+	if !(*_all) && isHidden(fi.Name()) {
+		report.skip()
+		return nil
+	}
+
+	return &node{
+		FileInfo:  fi,
+		path:      n.path + archiveSep + path.Clean("/" + name)[1:],
+		i:         n.i,
+		depth:     n.depth + 1,
+		container: n.path,
+	}
+}
+
+// hash() computes the digest of the member by the h hash.Hash like the digester
+// does, the digest recorded in the journal will be reused.
+func (n *node) hash(exit trigger, h hash.Hash) {
+	start := time.Now()
+	if r := jnl.lookup(n); r != nil {
+		n.sum, n.codec, n.resumed = r.sum, r.codec, true // Computed by the previous run.
+	} else {
+		h.Reset()
+		n.sum, n.err = n.digest(exit, h)
+	}
+	n.settle(start)
+}
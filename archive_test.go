@@ -0,0 +1,201 @@
+// archive_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/md5"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNodeMembers(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(dir)
+	creator = md5.New
+
+	files := map[string]string{
+		"foo/a.txt": "Hello",
+		"foo/b.txt": "World",
+		"./bar.txt": "",
+		"foo/.c":    "hidden",
+	}
+
+	writeTar := func(w io.Writer) {
+		tw := tar.NewWriter(w)
+		tw.WriteHeader(&tar.Header{Name: "foo/", Typeflag: tar.TypeDir, Mode: 0755})
+		for name, content := range files {
+			tw.WriteHeader(&tar.Header{Name: name, Size: int64(len(content)), Mode: 0644})
+			tw.Write([]byte(content))
+		}
+		tw.Close()
+	}
+
+	f, _ := os.Create(filepath.Join(dir, "a.tar"))
+	writeTar(f)
+	f.Close()
+
+	f, _ = os.Create(filepath.Join(dir, "a.tar.gz"))
+	gw := gzip.NewWriter(f)
+	writeTar(gw)
+	gw.Close()
+	f.Close()
+
+	f, _ = os.Create(filepath.Join(dir, "a.zip"))
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	f.Close()
+
+	ioutil.WriteFile(filepath.Join(dir, "plain.txt"), []byte("not an archive"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "broken.zip"), []byte("PK\x03\x04broken"), 0644)
+
+	for _, env := range []struct {
+		name   string
+		packed bool
+		paths  []string
+	}{
+		{"a.tar", true, []string{"bar.txt", "foo/a.txt", "foo/b.txt"}},
+		{"a.tar.gz", true, []string{"bar.txt", "foo/a.txt", "foo/b.txt"}},
+		{"a.zip", true, []string{"bar.txt", "foo/a.txt", "foo/b.txt"}},
+		{"plain.txt", false, nil},
+		{"broken.zip", false, nil},
+	} {
+		var (
+			a       = assert.New(t)
+			archive = (&node{}).init(filepath.Join(dir, env.name))
+			packed  = archive.unpack(make(trigger), md5.New())
+			ns      = archive.members
+		)
+
+		a.Equalf(env.packed, packed, "%+v", env)
+		a.Equalf(env.packed, archive.packed, "%+v", env)
+		a.Equalf(!env.packed, archive.isregular(), "%+v", env)
+		a.Equalf(len(env.paths), len(ns), "%+v", env)
+		for i, m := range ns {
+			name := env.paths[i]
+			a.Equalf(archive.path+archiveSep+name, m.path, "%+v", env)
+			a.Equalf(archive.path, m.container, "%+v", env)
+
+			sum := md5.Sum([]byte(files[name]))
+			if name == "bar.txt" {
+				sum = md5.Sum([]byte(files["./bar.txt"]))
+			}
+			a.Equalf(sum[:], m.sum, "%+v", env)
+			a.False(m.unpack(make(trigger), md5.New()))
+		}
+	}
+
+	// Hard links share digests of their targets, and a link to a missing
+	// member is reported.
+	f, _ = os.Create(filepath.Join(dir, "links.tar"))
+	tw := tar.NewWriter(f)
+	tw.WriteHeader(&tar.Header{Name: "a.txt", Size: 5, Mode: 0644})
+	tw.Write([]byte("Hello"))
+	tw.WriteHeader(&tar.Header{Name: "b.txt", Typeflag: tar.TypeLink, Linkname: "./a.txt", Mode: 0644})
+	tw.WriteHeader(&tar.Header{Name: "c.txt", Typeflag: tar.TypeLink, Linkname: "none", Mode: 0644})
+	tw.Close()
+	f.Close()
+
+	links := (&node{}).init(filepath.Join(dir, "links.tar"))
+	links.unpack(make(trigger), md5.New())
+	if ns := links.members; assert.Len(t, ns, 3) {
+		sum := md5.Sum([]byte("Hello"))
+		assert.Equal(t, sum[:], ns[0].sum)
+		assert.Equal(t, sum[:], ns[1].sum)
+		assert.Error(t, ns[2].err)
+	}
+
+	// Tar archives compressed by xz are not supported, but other xz files are
+	// hashed like regular files.
+	xz := "\xfd7zXZ\x00\x00\x04\xe6\xd6\xb4\x46"
+	for _, env := range []struct {
+		name string
+		ok   bool
+	}{
+		{"a.tar.xz", false},
+		{"a.TXZ", false},
+		{"data.xz", true},
+	} {
+		ioutil.WriteFile(filepath.Join(dir, env.name), []byte(xz), 0644)
+		archive := (&node{}).init(filepath.Join(dir, env.name))
+		assert.Falsef(t, archive.unpack(make(trigger), md5.New()), "%+v", env)
+		assert.Nilf(t, archive.members, "%+v", env)
+		assert.Equalf(t, env.ok, archive.err == nil, "%+v", env)
+		assert.Falsef(t, archive.packed, "%+v", env)
+	}
+}
+
+func TestArchives(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(dir)
+
+	zf, _ := os.Create(filepath.Join(dir, "a.zip"))
+	zw := zip.NewWriter(zf)
+	for _, name := range []string{"y", "x"} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(name))
+	}
+	zw.Close()
+	zf.Close()
+	ioutil.WriteFile(filepath.Join(dir, "b"), []byte("b"), 0644)
+
+	archive, factory := *_archive, creator
+	defer func() { *_archive, creator, jnl = archive, factory, nil }()
+	*_archive, creator = true, md5.New
+
+	run := func() (ns []*node) {
+		roots := []string{filepath.Join(dir, "a.zip"), filepath.Join(dir, "b")}
+		for n := range queue(digester(make(trigger), walk(make(trigger), roots))) {
+			ns = append(ns, n)
+		}
+		return ns
+	}
+
+	// Members are hashed by digesters and outputted right after the archive.
+	ns := run()
+	if assert.Len(t, ns, 4) {
+		for i, name := range []string{"a.zip", "a.zip!x", "a.zip!y", "b"} {
+			sum := md5.Sum([]byte(name[len(name)-1:]))
+			assert.Equal(t, filepath.Join(dir, name), ns[i].path)
+			if i > 0 {
+				assert.Equal(t, sum[:], ns[i].sum)
+			}
+		}
+		assert.True(t, ns[0].packed)
+		assert.Equal(t, ns[0].i, ns[1].i)
+	}
+
+	// Digests of members recorded in the journal are reused.
+	path := filepath.Join(dir, "go-hash.journal")
+	j, err := (&journal{}).open(path, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	ns[1].sum = []byte{0x01}
+	for range j.record(toInput(ns)) {
+	}
+	j.close(false)
+
+	if jnl, err = (&journal{}).open(path, nil); !assert.NoError(t, err) {
+		return
+	}
+	defer jnl.close(true)
+	if ns = run(); assert.Len(t, ns, 4) {
+		assert.Equal(t, []byte{0x01}, ns[1].sum)
+	}
+}
@@ -0,0 +1,161 @@
+// bencode.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+)
+
+// bencode() encodes the value in bencoding, which is used by BitTorrent metainfo
+// files. The value can be an integer, a string, a byte slice, a list or a dict,
+// other types are ignored. Keys of dicts are sorted as raw strings.
+func bencode(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case int:
+		fprintf(buf, "i%de", v)
+	case int64:
+		fprintf(buf, "i%de", v)
+	case string:
+		fprintf(buf, "%d:%s", len(v), v)
+	case []byte:
+		fprintf(buf, "%d:%s", len(v), v)
+	case []interface{}:
+		buf.WriteByte('l')
+		for _, e := range v {
+			bencode(buf, e)
+		}
+		buf.WriteByte('e')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		buf.WriteByte('d')
+		for _, k := range keys {
+			bencode(buf, k)
+			bencode(buf, v[k])
+		}
+		buf.WriteByte('e')
+	}
+}
+
+// bdecode() decodes the bencoded data. Integers are decoded as int64, strings
+// are decoded as string, lists are decoded as []interface{} and dicts are
+// decoded as map[string]interface{}.
+func bdecode(data []byte) (interface{}, error) {
+	d := &bdecoder{data: data}
+	v, err := d.value(0)
+	if err == nil && d.pos != len(data) {
+		err = errorf("trailing data at offset %d", d.pos)
+	}
+	return v, err
+}
+
+// The maximal nesting depth of lists and dicts.
+const maxBencodeDepth = 256
+
+// bdecoder decodes bencoded data.
+type bdecoder struct {
+	data []byte
+	pos  int
+}
+
+// value() decodes the value at the current position.
+func (d *bdecoder) value(depth int) (interface{}, error) {
+	if d.pos >= len(d.data) {
+		return nil, errorf("unexpected end of data")
+	} else if depth > maxBencodeDepth {
+		return nil, errorf("nesting too deep at offset %d", d.pos)
+	}
+
+	switch c := d.data[d.pos]; {
+	case c == 'i':
+		d.pos++
+		str, err := d.until('e')
+		if err != nil {
+			return nil, err
+		}
+
+		i, err := strconv.ParseInt(str, 10, 64)
+		if err != nil || str != strconv.FormatInt(i, 10) {
+			return nil, errorf("invalid integer '%s'", str)
+		}
+		return i, nil
+	case c == 'l':
+		d.pos++
+		list := []interface{}{}
+		for d.pos < len(d.data) && d.data[d.pos] != 'e' {
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, d.end()
+	case c == 'd':
+		d.pos++
+		dict := make(map[string]interface{})
+		for d.pos < len(d.data) && d.data[d.pos] != 'e' {
+			k, err := d.str()
+			if err != nil {
+				return nil, err
+			}
+
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			dict[k] = v
+		}
+		return dict, d.end()
+	case c >= '0' && c <= '9':
+		return d.str()
+	default:
+		return nil, errorf("invalid character '%c' at offset %d", c, d.pos)
+	}
+}
+
+// str() decodes the string at the current position.
+func (d *bdecoder) str() (string, error) {
+	str, err := d.until(':')
+	if err != nil {
+		return "", err
+	}
+
+	size, err := strconv.Atoi(str)
+	if err != nil || size < 0 || size > len(d.data)-d.pos {
+		return "", errorf("invalid string length '%s'", str)
+	}
+	d.pos += size
+	return string(d.data[d.pos-size : d.pos]), nil
+}
+
+// until() returns data between the current position and the delimiter, and
+// moves the position after the delimiter.
+func (d *bdecoder) until(delim byte) (string, error) {
+	i := bytes.IndexByte(d.data[d.pos:], delim)
+	if i < 0 {
+		return "", errorf("unexpected end of data")
+	}
+	str := string(d.data[d.pos : d.pos+i])
+	d.pos += i + 1
+	return str, nil
+}
+
+// end() consumes the end of a list or a dict.
+func (d *bdecoder) end() error {
+	if d.pos >= len(d.data) {
+		return errorf("unexpected end of data")
+	}
+	d.pos++
+	return nil
+}
@@ -0,0 +1,44 @@
+// bencode_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBencode(t *testing.T) {
+	for _, env := range []struct {
+		v      interface{}
+		result string
+	}{
+		{int64(-42), "i-42e"},
+		{"spam", "4:spam"},
+		{[]byte{}, "0:"},
+		{[]interface{}{"spam", int64(42)}, "l4:spami42ee"},
+		{map[string]interface{}{"spam": []interface{}{}, "cow": "moo"}, "d3:cow3:moo4:spamlee"},
+	} {
+		a := assert.New(t)
+		buf := &bytes.Buffer{}
+		bencode(buf, env.v)
+		a.Equalf(env.result, buf.String(), "%+v", env)
+
+		v, err := bdecode(buf.Bytes())
+		a.NoErrorf(err, "%+v", env)
+		if b, ok := env.v.([]byte); ok {
+			env.v = string(b)
+		}
+		a.Equalf(env.v, v, "%+v", env)
+	}
+
+	for _, data := range []string{"", "i42", "i-0e", "i04e", "5:spam", "-1:", "l4:spam", "di1e1:ae", "d1:a", "x", "i1ei2e"} {
+		_, err := bdecode([]byte(data))
+		assert.Errorf(t, err, "%q", data)
+	}
+}
@@ -0,0 +1,184 @@
+// blockhash.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"crypto/sha256"
+	"hash"
+)
+
+// Sizes of blocks of the Dropbox content hash and the Amazon Glacier tree hash.
+const (
+	dropboxBlockSize = 4 << 20
+	glacierChunkSize = 1 << 20
+)
+
+// blocks splits the data into fixed-size blocks and computes the digest of each
+// block. A block is complete when it's full and more data is written,
+// so the last block is never empty unless there is no data.
+type blocks struct {
+	size int
+	h    hash.Hash
+	n    int // The number of bytes written to the current block.
+}
+
+// write() writes data to blocks, and calls the done function with the digest of
+// each complete block.
+func (b *blocks) write(p []byte, done func(sum []byte)) {
+	for len(p) > 0 {
+		if b.n == b.size {
+			done(b.h.Sum(nil))
+			b.h.Reset()
+			b.n = 0
+		}
+
+		n := b.size - b.n
+		if n > len(p) {
+			n = len(p)
+		}
+		b.h.Write(p[:n])
+		b.n, p = b.n+n, p[n:]
+	}
+}
+
+// last() returns the digest of the last block.
+func (b *blocks) last() []byte {
+	return b.h.Sum(nil)
+}
+
+// reset() resets blocks to the initial state, digests of blocks are computed by
+// the h hash.Hash.
+func (b *blocks) reset(size int, h hash.Hash) {
+	b.size, b.h, b.n = size, h, 0
+}
+
+// dropbox computes the content hash of Dropbox, which is the sha256 digest of
+// the concatenated sha256 digests of 4 MiB blocks.
+type dropbox struct {
+	blocks
+	sums []byte
+}
+
+// newDropbox() creates a hash.Hash instance computing Dropbox content hashes.
+func newDropbox() hash.Hash {
+	d := &dropbox{}
+	d.Reset()
+	return d
+}
+
+// Write() writes data to the hash.
+func (d *dropbox) Write(p []byte) (int, error) {
+	d.write(p, func(sum []byte) { d.sums = append(d.sums, sum...) })
+	return len(p), nil
+}
+
+// Sum() appends the content hash to b and returns the resulting slice. It
+// doesn't change the underlying state.
+func (d *dropbox) Sum(b []byte) []byte {
+	h := sha256.New()
+	h.Write(d.sums)
+	if d.n > 0 {
+		h.Write(d.last()) // There is no block if the data is empty.
+	}
+	return h.Sum(b)
+}
+
+// Reset() resets the hash to its initial state.
+func (d *dropbox) Reset() {
+	d.reset(dropboxBlockSize, sha256.New())
+	d.sums = nil
+}
+
+// Size() returns the number of bytes Sum() will return.
+func (d *dropbox) Size() int {
+	return sha256.Size
+}
+
+// BlockSize() returns the block size of sha256.
+func (d *dropbox) BlockSize() int {
+	return sha256.BlockSize
+}
+
+// A treeNode is the root of a complete binary subtree of the tree hash.
+type treeNode struct {
+	sum   []byte
+	level int
+}
+
+// treeHash computes tree hashes like the Amazon Glacier tree hash and the Tiger
+// tree hash. Digests of fixed-size leaves are the leaves of the tree, and nodes
+// are combined in pairs from left to right on each level, and the last node is
+// promoted to the upper level if it has no sibling.
+//
+// Complete subtrees are merged as soon as possible, so only one node for each
+// level is held in memory. The remained nodes are merged from right to left at
+// last, which produces the same tree.
+type treeHash struct {
+	blocks
+	stack  []treeNode
+	size   int                             // The size of leaves.
+	leaf   func() hash.Hash                // Creates the hash of leaves.
+	parent func(left, right []byte) []byte // Returns the digest of the parent.
+}
+
+// newGlacier() creates a hash.Hash instance computing Glacier tree hashes, the
+// leaves are sha256 digests of 1 MiB chunks and each parent is the sha256
+// digest of its concatenated children.
+func newGlacier() hash.Hash {
+	t := &treeHash{size: glacierChunkSize, leaf: sha256.New, parent: parent}
+	t.Reset()
+	return t
+}
+
+// Write() writes data to the hash.
+func (t *treeHash) Write(p []byte) (int, error) {
+	t.write(p, func(sum []byte) {
+		node := treeNode{sum, 0}
+		for len(t.stack) > 0 && t.stack[len(t.stack)-1].level == node.level {
+			top := t.stack[len(t.stack)-1]
+			t.stack = t.stack[:len(t.stack)-1]
+			node = treeNode{t.parent(top.sum, node.sum), node.level + 1}
+		}
+		t.stack = append(t.stack, node)
+	})
+	return len(p), nil
+}
+
+// Sum() appends the tree hash to b and returns the resulting slice. It doesn't
+// change the underlying state.
+func (t *treeHash) Sum(b []byte) []byte {
+	sum := t.last()
+	for i := len(t.stack) - 1; i >= 0; i-- {
+		sum = t.parent(t.stack[i].sum, sum)
+	}
+	return append(b, sum...)
+}
+
+// Reset() resets the hash to its initial state.
+func (t *treeHash) Reset() {
+	t.reset(t.size, t.leaf())
+	t.stack = nil
+}
+
+// Size() returns the number of bytes Sum() will return.
+func (t *treeHash) Size() int {
+	return t.h.Size()
+}
+
+// BlockSize() returns the block size of the hash of leaves.
+func (t *treeHash) BlockSize() int {
+	return t.h.BlockSize()
+}
+
+// parent() returns the digest of the parent of two nodes.
+func parent(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
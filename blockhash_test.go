@@ -0,0 +1,74 @@
+// blockhash_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// chunks() returns sha256 digests of fixed-size chunks of the data.
+func chunks(data []byte, size int) [][]byte {
+	var sums [][]byte
+	for i := 0; i < len(data); i += size {
+		j := i + size
+		if j > len(data) {
+			j = len(data)
+		}
+		sum := sha256.Sum256(data[i:j])
+		sums = append(sums, sum[:])
+	}
+	return sums
+}
+
+func TestDropbox(t *testing.T) {
+	for _, size := range []int{0, 1, dropboxBlockSize, dropboxBlockSize + 1, 2*dropboxBlockSize + 3} {
+		a := assert.New(t)
+		data := bytes.Repeat([]byte{'x'}, size)
+		result := sha256.Sum256(bytes.Join(chunks(data, dropboxBlockSize), nil))
+
+		h := newDropbox()
+		h.Write(data)
+		a.Equalf(result[:], h.Sum(nil), "%d", size)
+		a.Equalf(result[:], h.Sum(nil), "%d", size)
+	}
+}
+
+func TestGlacier(t *testing.T) {
+	for _, size := range []int{0, 1, glacierChunkSize, glacierChunkSize + 1, 3 * glacierChunkSize, 5*glacierChunkSize + 7, 7 * glacierChunkSize} {
+		a := assert.New(t)
+		data := bytes.Repeat([]byte{'x'}, size)
+
+		// Combines nodes level by level.
+		level := chunks(data, glacierChunkSize)
+		if len(level) == 0 {
+			sum := sha256.Sum256(nil)
+			level = [][]byte{sum[:]}
+		}
+		for len(level) > 1 {
+			var next [][]byte
+			for i := 0; i < len(level); i += 2 {
+				if i+1 < len(level) {
+					next = append(next, parent(level[i], level[i+1]))
+				} else {
+					next = append(next, level[i])
+				}
+			}
+			level = next
+		}
+
+		h := newGlacier()
+		for p := data; len(p) > 0; p = p[len(p)/2+1:] {
+			h.Write(p[:len(p)/2+1])
+		}
+		a.Equalf(level[0], h.Sum(nil), "%d", size)
+		a.Equalf(level[0], h.Sum(nil), "%d", size)
+	}
+}
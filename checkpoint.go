@@ -0,0 +1,173 @@
+// checkpoint.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/json"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Intermediate hash states of a large file will be saved once this number of
+// bytes have been read since the last save. Files whose size is not greater
+// than it won't be checkpointed at all.
+const checkpointInterval = 256 << 20
+
+// A directory stores serialized hash states of files, which are used to resume
+// computing digests instead of reading files from the beginning.
+type stateStore string
+
+// Serialized hash state of a file. The size and the mtime fields are used
+// to check whether the file has changed since the state was saved; the tail
+// field is only used by incremental states.
+type hashState struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+	Offset  int64  `json:"offset"`
+	State   []byte `json:"state"`
+	Tail    []byte `json:"tail,omitempty"`
+}
+
+// file() returns the path of the state file of a node. States computed by
+// different algorithms are stored in different files.
+func (s stateStore) file(n *node, kind string) string {
+	abs, err := filepath.Abs(n.path)
+	if err != nil {
+		abs = n.path
+	}
+	return filepath.Join(string(s), sprintf("%x.%s", sha256.Sum256([]byte(*_algo+"\x00"+abs)), kind))
+}
+
+// load() reads the hash state of a node from the state file. If the file
+// doesn't exist or its content is invalid, returns nil.
+func (s stateStore) load(file string) *hashState {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+
+	hs := &hashState{}
+	if json.Unmarshal(data, hs) != nil {
+		return nil
+	}
+	return hs
+}
+
+// save() writes the hash state to the state file. The state file is replaced
+// atomically, so an interrupted save won't corrupt the previous state.
+func (s stateStore) save(file string, hs *hashState) error {
+	data, err := json.Marshal(hs)
+	if err != nil {
+		return err
+	}
+
+	tmp := file + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// Directory of checkpoint states. It's empty when the checkpoint option
+// is not specified.
+var checkpoints stateStore
+
+// checkpoint tracks the intermediate hash state of a large file when
+// computing its digest.
+type checkpoint struct {
+	file  string    // State file
+	state hashState // The last saved state
+	h     encoding.BinaryMarshaler
+}
+
+// checkpoint() returns a checkpoint instance of a node if its intermediate
+// hash states can be saved, otherwise returns nil. The previous state will
+// be restored to the h hash.Hash if the file hasn't changed since then. States
+// of decompressed data are never saved, because their offsets can't be used
+// to seek in the file.
+func (s stateStore) checkpoint(n *node, h hash.Hash) *checkpoint {
+	m, ok := h.(encoding.BinaryMarshaler)
+	if s == "" || !ok || !n.isfile() || n.codec != "" || n.Size() <= checkpointInterval {
+		return nil
+	}
+
+	c := &checkpoint{
+		file: s.file(n, "checkpoint"),
+		state: hashState{
+			Path:    n.path,
+			Size:    n.Size(),
+			ModTime: n.ModTime().UnixNano(),
+		},
+		h: m,
+	}
+
+	hs := s.load(c.file)
+	if hs == nil || hs.Path != n.path || hs.Size != n.Size() || hs.ModTime != c.state.ModTime {
+		return c // The file has changed, don't resume.
+	}
+
+	if u, ok := h.(encoding.BinaryUnmarshaler); ok && u.UnmarshalBinary(hs.State) == nil {
+		c.state = *hs
+	} else {
+		h.Reset()
+	}
+	return c
+}
+
+// offset() returns the number of bytes have been hashed in the restored state.
+func (c *checkpoint) offset() int64 {
+	if c == nil {
+		return 0
+	}
+	return c.state.Offset
+}
+
+// seek() sets the offset of the reader to the offset of the restored state.
+// If the reader can't seek, the restored state will be discarded.
+func (c *checkpoint) seek(r io.Reader, h hash.Hash) {
+	if c == nil || c.state.Offset == 0 {
+		return
+	}
+
+	if s, ok := r.(io.Seeker); ok {
+		if _, err := s.Seek(c.state.Offset, io.SeekStart); err == nil {
+			return
+		}
+	}
+	h.Reset()
+	c.state.Offset = 0
+}
+
+// update() saves the current hash state if enough bytes have been read since
+// the last save or the force parameter is true.
+func (c *checkpoint) update(offset int64, force bool) {
+	if c == nil || offset == c.state.Offset || (!force && offset-c.state.Offset < checkpointInterval) {
+		return
+	}
+
+	state, err := c.h.MarshalBinary()
+	if err != nil {
+		return
+	}
+
+	c.state.Offset, c.state.State = offset, state
+	checkpoints.save(c.file, &c.state)
+}
+
+// done() removes the state file after the digest of the file has been computed.
+func (c *checkpoint) done() {
+	if c != nil {
+		os.Remove(c.file)
+	}
+}
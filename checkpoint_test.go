@@ -0,0 +1,82 @@
+// checkpoint_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"crypto/md5"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// fileInfo is a fake os.FileInfo implementation for tests.
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi fileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi fileInfo) Sys() interface{}   { return nil }
+
+func TestCheckpoint(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "checkpoint")
+	defer os.RemoveAll(dir)
+	checkpoints = stateStore(dir)
+	defer func() { checkpoints = "" }()
+
+	var (
+		a     = assert.New(t)
+		mtime = time.Now()
+		big   = &node{path: "big", FileInfo: fileInfo{"big", 1 << 30, 0644, mtime}}
+		small = &node{path: "small", FileInfo: fileInfo{"small", 1 << 10, 0644, mtime}}
+		data  = []byte("Hello, World!")
+	)
+
+	a.Nil(checkpoints.checkpoint(small, md5.New()))
+	a.Nil(checkpoints.checkpoint(&node{}, md5.New()))
+	a.Nil(checkpoints.checkpoint(&node{path: "big.gz", FileInfo: big.FileInfo, codec: "gzip"}, md5.New()))
+
+	h := md5.New()
+	c := checkpoints.checkpoint(big, h)
+	a.NotNil(c)
+	a.Equal(int64(0), c.offset())
+
+	h.Write(data)
+	c.update(int64(len(data)), false)
+	a.Nil(checkpoints.load(c.file))
+	c.update(int64(len(data)), true)
+	a.NotNil(checkpoints.load(c.file))
+
+	// The hash state should be restored.
+	h = md5.New()
+	c = checkpoints.checkpoint(big, h)
+	a.Equal(int64(len(data)), c.offset())
+	a.Equal(md5.Sum(data), toArray(h.Sum(nil)))
+
+	// The file has changed, so the hash state should be discarded.
+	big.FileInfo = fileInfo{"big", 1<<30 + 1, 0644, mtime}
+	h = md5.New()
+	c = checkpoints.checkpoint(big, h)
+	a.Equal(int64(0), c.offset())
+
+	c.done()
+	a.Nil(checkpoints.load(c.file))
+}
+
+func toArray(sum []byte) (array [md5.Size]byte) {
+	copy(array[:], sum)
+	return array
+}
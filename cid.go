@@ -0,0 +1,266 @@
+// cid.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"hash"
+	"strings"
+)
+
+// Codes of hash algorithms in multihashes, which are defined in the multicodec
+// table. Git object IDs are also multihashes of the git-raw codec.
+var multihashCodes = map[string]uint64{
+	"md4":        0xd4,
+	"md5":        0xd5,
+	"sha1":       0x11,
+	"sha224":     0x1013,
+	"sha256":     0x12,
+	"sha384":     0x20,
+	"sha512":     0x13,
+	"sha512/224": 0x1014,
+	"sha512/256": 0x1015,
+	"git-sha1":   0x11,
+	"git-sha256": 0x12,
+}
+
+// Parameters of UnixFS DAGs built by the default importer of IPFS, which splits
+// files into 256 KiB raw leaves and links them in a balanced layout.
+const (
+	cidVersion   = 0x01
+	cidRaw       = 0x55
+	cidDagPB     = 0x70
+	cidChunkSize = 256 << 10
+	cidMaxLinks  = 174
+)
+
+// The lowercase base32 encoding used by multibase strings prefixed with 'b'.
+var multibase32 = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// encodeMultihash() encodes the digest as a base58 multihash, which is prefixed
+// with the code of the hash algorithm and the length of the digest.
+func encodeMultihash(sum []byte) string {
+	return encodeBase58(multihash(multihashCodes[*_algo], sum))
+}
+
+// decodeMultihash() decodes a base58 multihash, it's the reverse operation of
+// the encodeMultihash() function. The code of the hash algorithm should be the
+// selected one.
+func decodeMultihash(str string) ([]byte, error) {
+	data, err := decodeBase58(str)
+	if err != nil {
+		return nil, err
+	}
+
+	code, n := uvarint(data)
+	if n <= 0 || code != multihashCodes[*_algo] {
+		return nil, errorf("invalid multihash code")
+	}
+	data = data[n:]
+
+	size, n := uvarint(data)
+	if n <= 0 || size != uint64(len(data)-n) {
+		return nil, errorf("invalid multihash length")
+	}
+	return data[n:], nil
+}
+
+// encodeMultibase() encodes the data as a lowercase base32 multibase string.
+func encodeMultibase(data []byte) string {
+	return "b" + multibase32.EncodeToString(data)
+}
+
+// decodeMultibase() decodes a lowercase base32 multibase string, it's the
+// reverse operation of the encodeMultibase() function.
+func decodeMultibase(str string) ([]byte, error) {
+	if !strings.HasPrefix(str, "b") {
+		return nil, errorf("unsupported multibase prefix")
+	}
+	return multibase32.DecodeString(strings.ToLower(str[1:]))
+}
+
+// multihash() returns the binary multihash of the digest.
+func multihash(code uint64, sum []byte) []byte {
+	buf := appendUvarint(nil, code)
+	buf = appendUvarint(buf, uint64(len(sum)))
+	return append(buf, sum...)
+}
+
+// A cidLink is a link to a block in a UnixFS DAG.
+type cidLink struct {
+	cid      []byte
+	tsize    uint64 // The size of all blocks in the sub-DAG.
+	filesize uint64 // The size of file data in the sub-DAG.
+}
+
+// unixfs computes the UnixFS CIDv1 of a file like 'ipfs add --cid-version=1'
+// does. Data is split into chunks which are raw leaves, then every 174 links on
+// the same level are linked by a dag-pb node until there is only one node, so
+// the DAG is balanced. Only the current chunk and links of incomplete nodes are
+// held in memory.
+type unixfs struct {
+	chunk  []byte
+	levels [][]cidLink
+	empty  bool // No chunk has been added.
+}
+
+// newUnixFS() creates a hash.Hash instance computing UnixFS CIDv1s.
+func newUnixFS() hash.Hash {
+	u := &unixfs{}
+	u.Reset()
+	return u
+}
+
+// Write() writes data to the current chunk, the chunk is added to the DAG when
+// it's full and more data is written.
+func (u *unixfs) Write(p []byte) (int, error) {
+	size := len(p)
+	for len(p) > 0 {
+		if len(u.chunk) == cidChunkSize {
+			u.leaf()
+		}
+		n := cidChunkSize - len(u.chunk)
+		if n > len(p) {
+			n = len(p)
+		}
+		u.chunk, p = append(u.chunk, p[:n]...), p[n:]
+	}
+	return size, nil
+}
+
+// Sum() appends the CID of the root to b and returns the resulting slice. It
+// doesn't change the underlying state.
+func (u *unixfs) Sum(b []byte) []byte {
+	levels := make([][]cidLink, len(u.levels))
+	for i := range u.levels {
+		levels[i] = append([]cidLink(nil), u.levels[i]...)
+	}
+
+	if len(u.chunk) > 0 || u.empty {
+		levels[0] = append(levels[0], rawLeaf(u.chunk))
+	}
+
+	for i := 0; ; i++ {
+		if i == len(levels)-1 && len(levels[i]) == 1 {
+			return append(b, levels[i][0].cid...)
+		}
+		if len(levels[i]) > 0 {
+			if i == len(levels)-1 {
+				levels = append(levels, nil)
+			}
+			levels[i+1] = append(levels[i+1], dagNode(levels[i]))
+		}
+	}
+}
+
+// Reset() resets the hash to its initial state.
+func (u *unixfs) Reset() {
+	u.chunk, u.levels, u.empty = make([]byte, 0, cidChunkSize), make([][]cidLink, 1), true
+}
+
+// Size() returns the number of bytes Sum() will return.
+func (u *unixfs) Size() int {
+	return len(cid(cidRaw, nil))
+}
+
+// BlockSize() returns the size of chunks.
+func (u *unixfs) BlockSize() int {
+	return cidChunkSize
+}
+
+// leaf() adds the current chunk to the DAG as a raw leaf. Links are replaced
+// by a dag-pb node on the upper level when there are enough links.
+func (u *unixfs) leaf() {
+	u.levels[0] = append(u.levels[0], rawLeaf(u.chunk))
+	u.chunk, u.empty = u.chunk[:0], false
+
+	for i := 0; len(u.levels[i]) == cidMaxLinks; i++ {
+		if i == len(u.levels)-1 {
+			u.levels = append(u.levels, nil)
+		}
+		u.levels[i+1] = append(u.levels[i+1], dagNode(u.levels[i]))
+		u.levels[i] = nil
+	}
+}
+
+// rawLeaf() returns the link to a raw leaf containing the data.
+func rawLeaf(data []byte) cidLink {
+	size := uint64(len(data))
+	return cidLink{cid(cidRaw, data), size, size}
+}
+
+// dagNode() returns the link to a dag-pb node whose data is a UnixFS file
+// linking to the blocks.
+func dagNode(links []cidLink) cidLink {
+	var (
+		data  = []byte{0x08, 0x02} // Type: File
+		node  []byte
+		tsize uint64
+		fsize uint64
+	)
+
+	for _, l := range links {
+		fsize += l.filesize
+	}
+	data = appendField(data, 3, fsize)
+	for _, l := range links {
+		data = appendField(data, 4, l.filesize)
+	}
+
+	// Links are encoded before the data in dag-pb nodes, and names of links
+	// are always present even if they're empty.
+	for _, l := range links {
+		link := appendBytes(nil, 1, l.cid)
+		link = appendBytes(link, 2, nil)
+		link = appendField(link, 3, l.tsize)
+		node = appendBytes(node, 2, link)
+		tsize += l.tsize
+	}
+	node = appendBytes(node, 1, data)
+
+	return cidLink{cid(cidDagPB, node), tsize + uint64(len(node)), fsize}
+}
+
+// cid() returns the binary CIDv1 of the block whose digest is sha256.
+func cid(codec uint64, block []byte) []byte {
+	sum := sha256.Sum256(block)
+	return append([]byte{cidVersion, byte(codec)}, multihash(multihashCodes["sha256"], sum[:])...)
+}
+
+// appendField() appends a varint field of protocol buffers to buf.
+func appendField(buf []byte, field int, v uint64) []byte {
+	return appendUvarint(appendUvarint(buf, uint64(field<<3)), v)
+}
+
+// appendBytes() appends a length-delimited field of protocol buffers to buf.
+func appendBytes(buf []byte, field int, data []byte) []byte {
+	buf = appendUvarint(buf, uint64(field<<3|2))
+	return append(appendUvarint(buf, uint64(len(data))), data...)
+}
+
+// appendUvarint() appends the unsigned varint form of v to buf.
+func appendUvarint(buf []byte, v uint64) []byte {
+	for ; v >= 0x80; v >>= 7 {
+		buf = append(buf, byte(v)|0x80)
+	}
+	return append(buf, byte(v))
+}
+
+// uvarint() decodes an unsigned varint from buf and returns the value and the
+// number of bytes read. The number is zero if buf is invalid.
+func uvarint(buf []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(buf) && i < 10; i++ {
+		v |= uint64(buf[i]&0x7f) << (7 * uint(i))
+		if buf[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
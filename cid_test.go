@@ -0,0 +1,91 @@
+// cid_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMultihash(t *testing.T) {
+	defer func() { *_algo, *_encoding = "md5", "hex" }()
+	*_algo, *_encoding = "sha256", "multihash"
+
+	a := assert.New(t)
+	sum := sha256.Sum256([]byte("hello world"))
+	a.Equal("QmaozNR7DZHQK1ZcU9p7QdrshMvXqWK6gpu5rmrkPdT3L4", encodeDigest(sum[:]))
+
+	data, err := decodeDigest("QmaozNR7DZHQK1ZcU9p7QdrshMvXqWK6gpu5rmrkPdT3L4")
+	a.NoError(err)
+	a.Equal(sum[:], data)
+
+	*_algo = "sha512"
+	_, err = decodeDigest("QmaozNR7DZHQK1ZcU9p7QdrshMvXqWK6gpu5rmrkPdT3L4")
+	a.Error(err)
+
+	// The sha1 code is a single byte, but the sha224 code isn't.
+	a.Equal([]byte{0x11, 0x02, 0xab, 0xcd}, multihash(multihashCodes["sha1"], []byte{0xab, 0xcd}))
+	a.Equal([]byte{0x93, 0x20, 0x01, 0xab}, multihash(multihashCodes["sha224"], []byte{0xab}))
+}
+
+func TestUnixFS(t *testing.T) {
+	defer func() { *_algo, *_encoding = "md5", "hex" }()
+	*_algo, *_encoding = "cid", "multibase"
+
+	for _, env := range []struct {
+		data   string
+		result string
+	}{
+		{"", "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"},
+		{"hello world", "bafkreifzjut3te2nhyekklss27nh3k72ysco7y32koao5eei66wof36n5e"},
+	} {
+		a := assert.New(t)
+		h := newUnixFS()
+		h.Write([]byte(env.data))
+		a.Equalf(env.result, encodeDigest(h.Sum(nil)), "%+v", env)
+
+		sum, err := decodeDigest(env.result)
+		a.NoErrorf(err, "%+v", env)
+		a.Equalf(h.Sum(nil), sum, "%+v", env)
+	}
+
+	// CIDs of multi-chunk files are cross-checked with an independent implementation
+	// of the balanced layout, which builds the DAG top-down like the go-unixfs importer.
+	for _, env := range []struct {
+		size   int
+		codec  byte
+		result string
+	}{
+		{cidChunkSize, cidRaw, "bafkreibuqvqdjs7thkihxtslshk7vxi5wcrw3vyq6cg4a6ay5otkdmyy4q"},
+		{cidChunkSize + 1, cidDagPB, "bafybeie7aeunxcpqubs3kw2ydsyhsvnbw25mk7dalglzzt3sb7fft7clmy"},
+		{cidChunkSize * cidMaxLinks, cidDagPB, "bafybeiebqie3vptlgvj2xwsoxic5ybddcglxdotbis2h6jkzz4alzn3mqu"},
+		{cidChunkSize*cidMaxLinks + 1, cidDagPB, "bafybeices4ok57x6pdd5qwdtavhw4dl2mumlibacngfkhdjjoap42b3ia4"},
+	} {
+		a := assert.New(t)
+		data := bytes.Repeat([]byte("0123456789"), env.size/10+1)[:env.size]
+
+		// Results are independent of how data is written.
+		h1, h2 := newUnixFS(), newUnixFS()
+		h1.Write(data)
+		for p := data; len(p) > 0; p = p[len(p)/3+1:] {
+			h2.Write(p[:len(p)/3+1])
+		}
+
+		sum := h1.Sum(nil)
+		a.Equalf(h1.Size(), len(sum), "%d", env.size)
+		a.Equalf(env.codec, sum[1], "%d", env.size)
+		a.Equalf(env.result, encodeMultibase(sum), "%d", env.size)
+		a.Equalf(sum, h2.Sum(nil), "%d", env.size)
+		a.Equalf(sum, h1.Sum(nil), "%d", env.size)
+
+		h1.Reset()
+		a.Equalf("bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku", encodeMultibase(h1.Sum(nil)), "%d", env.size)
+	}
+}
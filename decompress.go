@@ -0,0 +1,81 @@
+// decompress.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+)
+
+// Number of bytes peeked to validate a zlib stream, whose magic number is too
+// weak to distinguish it from plain data (eg. text starts with "x^").
+const zlibPeekSize = 64
+
+// readCloser combines a reader and the closer of the underlying file.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// decompress() detects the compression format of the data by magic bytes and
+// returns a reader of the decompressed data and the name of the format. gzip,
+// bzip2 and zlib are supported. If the data is not compressed, returns the
+// original data and an empty format name.
+func decompress(rc io.ReadCloser) (io.ReadCloser, string, error) {
+	var (
+		br       = bufio.NewReader(rc)
+		magic, _ = br.Peek(zlibPeekSize)
+	)
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, "", err
+		}
+		return readCloser{gz, rc}, "gzip", nil
+	case bytes.HasPrefix(magic, bzip2Magic) && len(magic) > 3 && '1' <= magic[3] && magic[3] <= '9':
+		return readCloser{bzip2.NewReader(br), rc}, "bzip2", nil
+	case iszlib(magic):
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return nil, "", err
+		}
+		return readCloser{zr, rc}, "zlib", nil
+	}
+
+	// Not compressed, rewind the file if possible to keep its capabilities
+	// (io.Seeker, io.ReaderAt) which are used by checkpoint states.
+	if s, ok := rc.(io.Seeker); ok {
+		if _, err := s.Seek(0, io.SeekStart); err == nil {
+			return rc, "", nil
+		}
+	}
+	return readCloser{br, rc}, "", nil
+}
+
+// iszlib() checks whether the data starts with a zlib stream. Besides the
+// header checksum, it tries to decompress the data to reduce false positives.
+func iszlib(data []byte) bool {
+	if len(data) < 2 || data[0]&0x0f != 8 || data[0]>>4 > 7 || (uint(data[0])<<8|uint(data[1]))%31 != 0 {
+		return false
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+
+	_, err = io.Copy(ioutil.Discard, zr)
+	return err == nil || err == io.ErrUnexpectedEOF
+}
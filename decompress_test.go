@@ -0,0 +1,74 @@
+// decompress_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDecompress(t *testing.T) {
+	var (
+		content = []byte(strings.Repeat("Hello, World!\n", 100))
+		gzData  = &bytes.Buffer{}
+		zData   = &bytes.Buffer{}
+	)
+
+	gw := gzip.NewWriter(gzData)
+	gw.Write(content)
+	gw.Close()
+
+	zw := zlib.NewWriter(zData)
+	zw.Write(content)
+	zw.Close()
+
+	for _, env := range []struct {
+		data   []byte
+		codec  string
+		result []byte
+		ok     bool
+	}{
+		{[]byte{}, "", []byte{}, true},
+		{content, "", content, true},
+		{[]byte("x^ is not a zlib stream"), "", []byte("x^ is not a zlib stream"), true},
+		{[]byte("BZh is not a bzip2 stream"), "", []byte("BZh is not a bzip2 stream"), true},
+		{gzData.Bytes(), "gzip", content, true},
+		{zData.Bytes(), "zlib", content, true},
+		{gzData.Bytes()[:5], "", nil, false},
+	} {
+		a := assert.New(t)
+		r, codec, err := decompress(ioutil.NopCloser(bytes.NewReader(env.data)))
+		a.Equalf(env.ok, err == nil, "%+v", env)
+		if err != nil {
+			continue
+		}
+
+		data, _ := ioutil.ReadAll(r)
+		a.Equalf(env.codec, codec, "%+v", env)
+		a.Equalf(env.result, data, "%+v", env)
+	}
+
+	// A plain file keeps its capabilities.
+	f, _ := ioutil.TempFile("", "plain.*.txt")
+	defer os.Remove(f.Name())
+	f.Write(content)
+	f.Seek(0, io.SeekStart)
+
+	r, codec, err := decompress(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "", codec)
+	assert.Equal(t, f, r)
+	f.Close()
+}
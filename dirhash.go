@@ -0,0 +1,107 @@
+// dirhash.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"crypto/sha256"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The prefix of hashes computed by the Hash1 algorithm of the dirhash package
+// in golang.org/x/mod, which are recorded in go.sum files.
+const h1Prefix = "h1:"
+
+// dirhashes() aggregates digests of files under each root into the Hash1 hash in
+// the dirhash mode, and outputs a node for each root instead of its files. The
+// Hash1 hash is the SHA-256 digest of the sorted summary whose lines are like
+// 'sha256  name'. The names of files in a directory are their relative paths
+// joined with the dirhash prefix, and the names of members in a zip file are
+// their paths in the zip file. A single file is named by its base name.
+func dirhashes(input chan *node) (output chan *node) {
+	if !(*_dirhash) {
+		return input
+	}
+
+	output = make(chan *node)
+	go func() {
+		var (
+			root  *node
+			names []string
+			sums  map[string][]byte
+			err   error
+		)
+
+		flush := func() {
+			if root == nil {
+				return
+			}
+
+			n := &node{path: root.path, i: root.i, err: err}
+			switch {
+			case n.err != nil:
+			case root.err != nil:
+				n.err = root.err
+			case root.isregular() && names == nil:
+				name := path.Join(*_dirhash_prefix, filepath.Base(root._path()))
+				names, sums[name] = []string{name}, root.sum
+				fallthrough
+			default:
+				sort.Strings(names)
+				h := sha256.New()
+				for _, name := range names {
+					fprintf(h, "%x  %s\n", sums[name], name)
+				}
+				n.sum = h.Sum(nil)
+				n.err = n.verify()
+			}
+			output <- n
+		}
+
+		// Nodes are walked in pre-order, so all descendants of a root node
+		// are right behind it.
+		for n := range input {
+			if n.depth == 0 {
+				flush()
+				root, names, sums, err = n, nil, make(map[string][]byte), nil
+				continue
+			}
+
+			if err != nil {
+				continue
+			} else if n.err != nil {
+				err = n.err
+				continue
+			} else if n.isdir() {
+				continue
+			} else if !n.isregular() {
+				err = errorf("%s is not a regular file", n.path)
+				continue
+			}
+
+			var name string
+			if n.container != "" {
+				name = strings.TrimPrefix(n.path, root.path+archiveSep)
+			} else {
+				rel, _ := filepath.Rel(root.path, n.path)
+				name = path.Join(*_dirhash_prefix, filepath.ToSlash(rel))
+			}
+
+			if strings.Contains(name, "\n") {
+				err = errorf("filenames with newlines are not supported")
+				continue
+			}
+			names, sums[name] = append(names, name), n.sum
+		}
+		flush()
+		close(output)
+	}()
+	return output
+}
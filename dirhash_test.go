@@ -0,0 +1,92 @@
+// dirhash_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirhashes(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "dirhash")
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"go.mod":      "module example.com/m\n",
+		".gitignore":  "*.tmp\n",
+		"a.go":        "package m\n",
+		"sub/b.go":    "package sub\n",
+		"sub/c/d.txt": "Hello, World!\n",
+	}
+
+	zf, _ := os.Create(filepath.Join(dir, "m.zip"))
+	zw := zip.NewWriter(zf)
+	for name, content := range files {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, "m", name)), 0755)
+		ioutil.WriteFile(filepath.Join(dir, "m", name), []byte(content), 0644)
+		w, _ := zw.Create("example.com/m@v1.0.0/" + name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	zf.Close()
+
+	// The go.mod file of github.com/stretchr/testify@v1.4.0, its hash is
+	// recorded in go.sum files.
+	ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/stretchr/testify\n\n"+
+		"require (\n\tgithub.com/davecgh/go-spew v1.1.0\n\tgithub.com/pmezard/go-difflib v1.0.0\n"+
+		"\tgithub.com/stretchr/objx v0.1.0\n\tgopkg.in/yaml.v2 v2.2.2\n)\n"), 0644)
+
+	// Computes the Hash1 hash of the files by its definition.
+	h := sha256.New()
+	for _, name := range []string{".gitignore", "a.go", "go.mod", "sub/b.go", "sub/c/d.txt"} {
+		fprintf(h, "%x  %s\n", sha256.Sum256([]byte(files[name])), "example.com/m@v1.0.0/"+name)
+	}
+	h1 := h1Prefix + base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	creator, sumSize, *_dirhash, *_all, *_depth = sha256.New, sha256.Size, true, true, math.MaxInt32
+	defer func() { *_dirhash, *_all, *_depth, *_dirhash_prefix = false, false, 1, "" }()
+
+	for _, env := range []struct {
+		root   string
+		prefix string
+		result string
+	}{
+		{filepath.Join(dir, "go.mod"), "", "h1:j7eGeouHqKxXV5pUuKE4zz7dFj8WfuZ+81PSLYec5m4="},
+		{filepath.Join(dir, "m"), "example.com/m@v1.0.0", h1},
+		{filepath.Join(dir, "m.zip"), "", h1},
+		{filepath.Join(dir, "m.zip"), "ignored", h1},
+	} {
+		a := assert.New(t)
+		*_dirhash_prefix = env.prefix
+
+		var ns []*node
+		for n := range dirhashes(queue(digester(make(trigger), walk(make(trigger), []string{env.root})))) {
+			ns = append(ns, n)
+		}
+
+		if a.Lenf(ns, 1, "%+v", env) {
+			a.NoErrorf(ns[0].err, "%+v", env)
+			a.Equalf(env.root, ns[0].path, "%+v", env)
+			a.Equalf(env.result, ns[0].encode(), "%+v", env)
+		}
+	}
+
+	// Non-regular files are not supported.
+	if os.Symlink("a.go", filepath.Join(dir, "m", "e.go")) == nil {
+		for n := range dirhashes(queue(digester(make(trigger), walk(make(trigger), []string{filepath.Join(dir, "m")})))) {
+			assert.Error(t, n.err)
+		}
+	}
+}
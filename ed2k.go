@@ -0,0 +1,183 @@
+// ed2k.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"encoding/hex"
+	"hash"
+	"math/bits"
+	"net/url"
+)
+
+// Parameters of MD4 and the eDonkey2000 hash, which splits files into chunks
+// of 9500 KiB.
+const (
+	md4Size       = 16
+	md4BlockSize  = 64
+	ed2kChunkSize = 9728000
+)
+
+// md4 computes MD4 digests which are defined in RFC 1320.
+type md4 struct {
+	state  [4]uint32
+	buf    [md4BlockSize]byte
+	nbuf   int
+	length uint64
+}
+
+// newMD4() creates a hash.Hash instance computing MD4 digests.
+func newMD4() hash.Hash {
+	m := &md4{}
+	m.Reset()
+	return m
+}
+
+// Write() writes data to the hash.
+func (m *md4) Write(p []byte) (int, error) {
+	size := len(p)
+	m.length += uint64(size)
+	for len(p) > 0 {
+		n := copy(m.buf[m.nbuf:], p)
+		m.nbuf, p = m.nbuf+n, p[n:]
+		if m.nbuf == md4BlockSize {
+			m.block(m.buf[:])
+			m.nbuf = 0
+		}
+	}
+	return size, nil
+}
+
+// Sum() appends the digest to b and returns the resulting slice. It doesn't
+// change the underlying state.
+func (m *md4) Sum(b []byte) []byte {
+	c := *m
+	c.Write(padding(0x80, m.length))
+	for _, s := range c.state {
+		b = append(b, byte(s), byte(s>>8), byte(s>>16), byte(s>>24))
+	}
+	return b
+}
+
+// Reset() resets the hash to its initial state.
+func (m *md4) Reset() {
+	*m = md4{state: [4]uint32{0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476}}
+}
+
+// Size() returns the number of bytes Sum() will return.
+func (m *md4) Size() int {
+	return md4Size
+}
+
+// BlockSize() returns the block size of MD4.
+func (m *md4) BlockSize() int {
+	return md4BlockSize
+}
+
+// Orders of words, shift amounts and additive constants in three rounds of MD4.
+var (
+	md4Orders = [3][16]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+		{0, 4, 8, 12, 1, 5, 9, 13, 2, 6, 10, 14, 3, 7, 11, 15},
+		{0, 8, 4, 12, 2, 10, 6, 14, 1, 9, 5, 13, 3, 11, 7, 15},
+	}
+	md4Shifts    = [3][4]int{{3, 7, 11, 19}, {3, 5, 9, 13}, {3, 9, 11, 15}}
+	md4Constants = [3]uint32{0, 0x5a827999, 0x6ed9eba1}
+)
+
+// block() compresses a 64-byte block into the state.
+func (m *md4) block(p []byte) {
+	var (
+		x          [16]uint32
+		a, b, c, d = m.state[0], m.state[1], m.state[2], m.state[3]
+	)
+
+	for i := range x {
+		x[i] = uint32(p[i*4]) | uint32(p[i*4+1])<<8 | uint32(p[i*4+2])<<16 | uint32(p[i*4+3])<<24
+	}
+
+	for r := 0; r < 3; r++ {
+		for i, k := range md4Orders[r] {
+			var f uint32
+			switch r {
+			case 0:
+				f = b&c | ^b&d
+			case 1:
+				f = b&c | b&d | c&d
+			case 2:
+				f = b ^ c ^ d
+			}
+			a = bits.RotateLeft32(a+f+x[k]+md4Constants[r], md4Shifts[r][i%4])
+			a, b, c, d = d, a, b, c
+		}
+	}
+
+	m.state[0] += a
+	m.state[1] += b
+	m.state[2] += c
+	m.state[3] += d
+}
+
+// ed2k computes eDonkey2000 hashes. The hash of a file smaller than a chunk is
+// its MD4 digest, otherwise it's the MD4 digest of the concatenated MD4 digests
+// of chunks. Like eMule, a digest of an empty chunk is appended when the size
+// of the file is a multiple of the chunk size.
+type ed2k struct {
+	blocks
+	sums []byte
+}
+
+// newEd2k() creates a hash.Hash instance computing eDonkey2000 hashes.
+func newEd2k() hash.Hash {
+	e := &ed2k{}
+	e.Reset()
+	return e
+}
+
+// Write() writes data to the hash.
+func (e *ed2k) Write(p []byte) (int, error) {
+	e.write(p, func(sum []byte) { e.sums = append(e.sums, sum...) })
+	return len(p), nil
+}
+
+// Sum() appends the eDonkey2000 hash to b and returns the resulting slice. It
+// doesn't change the underlying state.
+func (e *ed2k) Sum(b []byte) []byte {
+	sums := append(append([]byte(nil), e.sums...), e.last()...)
+	if e.n == e.size {
+		sums = newMD4().Sum(sums) // The empty chunk.
+	}
+
+	if len(sums) == md4Size {
+		return append(b, sums...)
+	}
+	h := newMD4()
+	h.Write(sums)
+	return h.Sum(b)
+}
+
+// Reset() resets the hash to its initial state.
+func (e *ed2k) Reset() {
+	e.reset(ed2kChunkSize, newMD4())
+	e.sums = nil
+}
+
+// Size() returns the number of bytes Sum() will return.
+func (e *ed2k) Size() int {
+	return md4Size
+}
+
+// BlockSize() returns the block size of MD4.
+func (e *ed2k) BlockSize() int {
+	return md4BlockSize
+}
+
+// ed2kLink() returns the ed2k link of the node like
+// 'ed2k://|file|name|size|hash|/', whose name is percent-encoded.
+func (n *node) ed2kLink() string {
+	return sprintf("ed2k://|file|%s|%d|%s|/", url.PathEscape(n.filename()), n.Size(), hex.EncodeToString(n.sum))
+}
@@ -0,0 +1,110 @@
+// ed2k_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// md4sum() returns the md4 digest of the data.
+func md4sum(data []byte) []byte {
+	h := newMD4()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func TestMD4(t *testing.T) {
+	for _, env := range []struct {
+		data   string
+		result string
+	}{
+		{"", "31d6cfe0d16ae931b73c59d7e0c089c0"},
+		{"a", "bde52cb31de33e46245e05fbdbd6fb24"},
+		{"abc", "a448017aaf21d8525fc10ae87aa6729d"},
+		{"message digest", "d9130a8164549fe818874806e1c7014b"},
+		{"abcdefghijklmnopqrstuvwxyz", "d79e1c308aa5bbcdeea8ed63df412da9"},
+		{strings.Repeat("1234567890", 8), "e33b4ddc9c38f2199c3e7b164fcc0536"},
+	} {
+		a := assert.New(t)
+		h := newMD4()
+		for p := env.data; len(p) > 0; p = p[len(p)/2+1:] {
+			h.Write([]byte(p[:len(p)/2+1]))
+		}
+		a.Equalf(env.result, hex.EncodeToString(h.Sum(nil)), "%+v", env)
+		a.Equalf(env.result, hex.EncodeToString(h.Sum(nil)), "%+v", env)
+	}
+}
+
+func TestEd2k(t *testing.T) {
+	for _, env := range []struct {
+		size   int
+		result string
+	}{
+		{0, "31d6cfe0d16ae931b73c59d7e0c089c0"},
+		{1, hex.EncodeToString(md4sum([]byte{'x'}))},
+		{ed2kChunkSize - 1, ""},
+		{ed2kChunkSize, ""},
+		{ed2kChunkSize + 1, ""},
+		{2 * ed2kChunkSize, ""},
+	} {
+		a := assert.New(t)
+		data := bytes.Repeat([]byte{'x'}, env.size)
+
+		if env.result == "" {
+			var sums [][]byte
+			for i := 0; i <= len(data); i += ed2kChunkSize {
+				j := i + ed2kChunkSize
+				if j > len(data) {
+					j = len(data)
+				}
+				sums = append(sums, md4sum(data[i:j]))
+			}
+			if len(sums) == 1 {
+				env.result = hex.EncodeToString(sums[0])
+			} else {
+				env.result = hex.EncodeToString(md4sum(bytes.Join(sums, nil)))
+			}
+		}
+
+		h := newEd2k()
+		h.Write(data)
+		a.Equalf(env.result, hex.EncodeToString(h.Sum(nil)), "%d", env.size)
+		a.Equalf(env.result, hex.EncodeToString(h.Sum(nil)), "%d", env.size)
+	}
+
+	// The eMule hash of a chunk of zeros.
+	h := newEd2k()
+	h.Write(make([]byte, ed2kChunkSize))
+	assert.Equal(t, "fc21d9af828f92a8df64beac3357425d", hex.EncodeToString(h.Sum(nil)))
+}
+
+func TestEd2kLink(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "ed2k")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "a b|c.txt")
+	a.NoError(ioutil.WriteFile(path, []byte("abc"), 0644))
+
+	n := (&node{}).init(path)
+	n.sum = md4sum([]byte("abc"))
+	a.Equal("ed2k://|file|a%20b%7Cc.txt|3|a448017aaf21d8525fc10ae87aa6729d|/", n.ed2kLink())
+
+	link := *_ed2k_link
+	defer func() { *_ed2k_link = link }()
+	*_ed2k_link = true
+	a.Equal(n.ed2kLink(), n.String())
+}
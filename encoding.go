@@ -0,0 +1,163 @@
+// encoding.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"strings"
+)
+
+// A digestEncoding specifies how to encode digests for outputting and how to decode
+// digests in checksum files or options.
+type digestEncoding struct {
+	encode func(sum []byte) string
+	decode func(str string) ([]byte, error)
+}
+
+// Hash algorithms allowed in Subresource Integrity strings.
+var sriAlgos = map[string]bool{"sha256": true, "sha384": true, "sha512": true}
+
+// encodings variable specifies all digest encodings supported by this tool.
+var encodings = map[string]digestEncoding{
+	"hex": {
+		hex.EncodeToString,
+		hex.DecodeString,
+	},
+	"oci": {
+		func(sum []byte) string { return algoName() + ":" + hex.EncodeToString(sum) },
+		func(str string) ([]byte, error) { return hex.DecodeString(strings.TrimPrefix(str, algoName()+":")) },
+	},
+	"sri": {
+		func(sum []byte) string { return algoName() + "-" + base64.StdEncoding.EncodeToString(sum) },
+		func(str string) ([]byte, error) { return decodeBase64(strings.TrimPrefix(str, algoName()+"-")) },
+	},
+	"base64": {
+		base64.StdEncoding.EncodeToString,
+		decodeBase64,
+	},
+	"base64url": {
+		base64.RawURLEncoding.EncodeToString,
+		decodeBase64,
+	},
+	"base32": {
+		base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString,
+		func(str string) ([]byte, error) {
+			return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(strings.TrimRight(str, "=")))
+		},
+	},
+	"base58": {
+		encodeBase58,
+		decodeBase58,
+	},
+	"multihash": {
+		encodeMultihash,
+		decodeMultihash,
+	},
+	"multibase": {
+		encodeMultibase,
+		decodeMultibase,
+	},
+	"nix32": {
+		encodeNix32,
+		decodeNix32,
+	},
+}
+
+// encodeDigest() encodes the digest by using the selected encoding. Hashes of
+// the dirhash option are always encoded in the 'h1:' form, and S3 ETags are
+// always encoded in hex with the number of parts.
+func encodeDigest(sum []byte) string {
+	switch {
+	case *_dirhash:
+		return h1Prefix + base64.StdEncoding.EncodeToString(sum)
+	case *_algo == "s3etag":
+		return encodeETag(sum)
+	default:
+		return encodings[*_encoding].encode(sum)
+	}
+}
+
+// decodeDigest() decodes the digest by using the selected encoding, it's the
+// reverse operation of the encodeDigest() function.
+func decodeDigest(str string) ([]byte, error) {
+	switch {
+	case *_dirhash:
+		return base64.StdEncoding.DecodeString(strings.TrimPrefix(str, h1Prefix))
+	case *_algo == "s3etag":
+		return decodeETag(str)
+	default:
+		return encodings[*_encoding].decode(str)
+	}
+}
+
+// decodeBase64() decodes a base64 string in the standard or the URL-safe
+// alphabet, the padding is optional.
+func decodeBase64(str string) ([]byte, error) {
+	str = strings.TrimRight(str, "=")
+	if strings.ContainsAny(str, "-_") {
+		return base64.RawURLEncoding.DecodeString(str)
+	}
+	return base64.RawStdEncoding.DecodeString(str)
+}
+
+// The alphabet of base58 used by Bitcoin and IPFS.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// encodeBase58() encodes the data in base58. Each leading zero byte is encoded
+// as the first character of the alphabet.
+func encodeBase58(data []byte) string {
+	var (
+		x    = new(big.Int).SetBytes(data)
+		base = big.NewInt(58)
+		mod  = new(big.Int)
+		buf  []byte
+	)
+
+	for x.Sign() > 0 {
+		x.DivMod(x, base, mod)
+		buf = append(buf, base58Alphabet[mod.Int64()])
+	}
+
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		buf = append(buf, base58Alphabet[0])
+	}
+
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	return string(buf)
+}
+
+// decodeBase58() decodes a base58 string, it's the reverse operation of the
+// encodeBase58() function.
+func decodeBase58(str string) ([]byte, error) {
+	var (
+		x     = new(big.Int)
+		base  = big.NewInt(58)
+		zeros int
+	)
+
+	for i := 0; i < len(str); i++ {
+		d := strings.IndexByte(base58Alphabet, str[i])
+		if d < 0 {
+			return nil, errorf("invalid base58 character '%c'", str[i])
+		}
+		x.Mul(x, base).Add(x, big.NewInt(int64(d)))
+	}
+
+	for zeros < len(str) && str[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), x.Bytes()...), nil
+}
@@ -0,0 +1,84 @@
+// encoding_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBase58(t *testing.T) {
+	for _, env := range []struct {
+		data   string
+		result string
+	}{
+		{"", ""},
+		{"\x00", "1"},
+		{"\x00\x00\x01", "112"},
+		{"hello world", "StV1DL6CwTryKyV"},
+	} {
+		a := assert.New(t)
+		a.Equalf(env.result, encodeBase58([]byte(env.data)), "%+v", env)
+
+		data, err := decodeBase58(env.result)
+		a.NoErrorf(err, "%+v", env)
+		a.Equalf(env.data, string(data), "%+v", env)
+	}
+
+	_, err := decodeBase58("0OIl")
+	assert.Error(t, err)
+}
+
+func TestEncodings(t *testing.T) {
+	defer func() { *_algo, *_encoding = "md5", "hex" }()
+
+	sum384 := sha512.Sum384([]byte("alert('Hello, world.');"))
+	sum256 := sha256.Sum256([]byte("alert('Hello, world.');"))
+
+	for _, env := range []struct {
+		algo     string
+		encoding string
+		sum      []byte
+		result   string
+	}{
+		{"sha384", "sri", sum384[:], "sha384-H8BRh8j48O9oYatfu5AZzq6A9RINhZO5H16dQZngK7T62em8MUt1FLm52t+eX6xO"},
+		{"sha256", "hex", sum256[:], "ab39cb72c44ec7818008fd9d9b4502282cc21be1e267582eaba6590e86ff4e78"},
+		{"sha256", "base64", sum256[:], "qznLcsROx4GACP2dm0UCKCzCG+HiZ1guq6ZZDob/Tng="},
+		{"sha256", "base64url", sum256[:], "qznLcsROx4GACP2dm0UCKCzCG-HiZ1guq6ZZDob_Tng"},
+		{"sha256", "base32", sum256[:], "VM44W4WEJ3DYDAAI7WOZWRICFAWMEG7B4JTVQLVLUZMQ5BX7JZ4A"},
+		{"sha256", "base58", sum256[:], "CXPq9znSHYzoEjWZTjzMtmaRMXiAgcGoc2VJomjcfdEP"},
+	} {
+		a := assert.New(t)
+		*_algo, *_encoding = env.algo, env.encoding
+		a.Equalf(env.result, encodeDigest(env.sum), "%+v", env)
+
+		sum, err := decodeDigest(env.result)
+		a.NoErrorf(err, "%+v", env)
+		a.Equalf(env.sum, sum, "%+v", env)
+	}
+
+	// Decoding is more tolerant than encoding.
+	*_algo = "sha256"
+	for _, env := range []struct {
+		encoding string
+		str      string
+	}{
+		{"hex", "AB39CB72C44EC7818008FD9D9B4502282CC21BE1E267582EABA6590E86FF4E78"},
+		{"base64", "qznLcsROx4GACP2dm0UCKCzCG-HiZ1guq6ZZDob_Tng"},
+		{"base64url", "qznLcsROx4GACP2dm0UCKCzCG+HiZ1guq6ZZDob/Tng="},
+		{"base32", "vm44w4wej3dydaai7wozwricfawmeg7b4jtvqlvluzmq5bx7jz4a===="},
+	} {
+		a := assert.New(t)
+		*_encoding = env.encoding
+		sum, err := decodeDigest(env.str)
+		a.NoErrorf(err, "%+v", env)
+		a.Equalf(sum256[:], sum, "%+v", env)
+	}
+}
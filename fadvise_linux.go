@@ -0,0 +1,21 @@
+// fadvise_linux.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build linux
+// +build amd64 arm64 loong64 mips64 mips64le ppc64 ppc64le riscv64 s390x
+
+package main
+
+import "syscall"
+
+const fadvDontNeed = 4 // POSIX_FADV_DONTNEED
+
+// fadvise() calls posix_fadvise(POSIX_FADV_DONTNEED) on the file descriptor.
+// (For 64-bit Linux)
+func fadvise(fd uintptr, offset, length int64) {
+	syscall.Syscall6(syscall.SYS_FADVISE64, fd, uintptr(offset), uintptr(length), fadvDontNeed, 0, 0)
+}
@@ -0,0 +1,13 @@
+// fadvise_other.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build !linux !amd64,!arm64,!loong64,!mips64,!mips64le,!ppc64,!ppc64le,!riscv64,!s390x
+
+package main
+
+// fadvise() does nothing. (For Other Systems)
+func fadvise(fd uintptr, offset, length int64) {}
@@ -0,0 +1,207 @@
+// git.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bytes"
+	"hash"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Modes of entries in git tree objects.
+const (
+	gitModeFile       = "100644"
+	gitModeExecutable = "100755"
+	gitModeSymlink    = "120000"
+	gitModeTree       = "40000"
+)
+
+// A sizedHash is a hash.Hash whose result depends on the size of the data, which
+// should be known before writing.
+type sizedHash interface {
+	hash.Hash
+	presize(size int64)
+}
+
+// gitObject computes git object IDs, which are digests of objects prefixed with
+// the header '<kind> <size>\0'. If the size of a blob isn't known before writing
+// (eg. the standard input), the data will be buffered until computing the sum.
+type gitObject struct {
+	hash.Hash
+	started bool
+	buf     []byte
+}
+
+// gitFactory specifies how to create a hash.Hash instance computing git object IDs.
+type gitFactory func() hash.Hash
+
+// normalize() converts a gitFactory instance to the corresponded factory instance.
+func (gf gitFactory) normalize() factory {
+	return func() hash.Hash { return &gitObject{Hash: gf()} }
+}
+
+// init() resets the hash and writes the header of an object.
+func (g *gitObject) init(kind string, size int64) *gitObject {
+	g.Reset()
+	fprintf(g.Hash, "%s %d\x00", kind, size)
+	g.started = true
+	return g
+}
+
+// presize() writes the header of a blob whose size is known.
+func (g *gitObject) presize(size int64) {
+	g.init("blob", size)
+}
+
+// Write() writes data to the hash, the data is buffered if the header hasn't
+// been written.
+func (g *gitObject) Write(p []byte) (int, error) {
+	if g.started {
+		return g.Hash.Write(p)
+	}
+	g.buf = append(g.buf, p...)
+	return len(p), nil
+}
+
+// Sum() appends the object ID to b and returns the resulting slice.
+func (g *gitObject) Sum(b []byte) []byte {
+	if !g.started {
+		buf := g.buf
+		g.init("blob", int64(len(buf))).Write(buf)
+	}
+	return g.Hash.Sum(b)
+}
+
+// Reset() resets the hash to its initial state.
+func (g *gitObject) Reset() {
+	g.Hash.Reset()
+	g.started, g.buf = false, nil
+}
+
+// isgit() checks whether the hash algorithm computes git object IDs.
+func isgit() bool {
+	return strings.HasPrefix(*_algo, "git-")
+}
+
+// A gitEntry is an entry in a git tree object.
+type gitEntry struct {
+	mode string
+	name string
+	sum  []byte
+}
+
+// trees() computes git tree IDs of directories if the hash algorithm computes
+// git object IDs. Nodes of directories are held until all of their descendants
+// have been outputted, because their trees can only be computed at that time.
+// Empty directories are not included in trees of their parents like git does.
+// If a descendant has an error, the tree can't be computed and the error will
+// be stored to the err field of the directory.
+func trees(input chan *node) (output chan *node) {
+	if !isgit() {
+		return input
+	}
+
+	output = make(chan *node)
+	go func() {
+		type dir struct {
+			*node
+			entries []gitEntry
+		}
+
+		// S is a stack of directories whose descendants are being outputted.
+		// Nodes are walked in pre-order, so a node belongs to the directory
+		// on the top of the stack whose depth is less than its depth.
+		var S []*dir
+		add := func(n *node, entry gitEntry) {
+			d := len(S) - 1
+			if d < 0 || S[d].depth != n.depth-1 || S[d].err != nil {
+				return
+			}
+
+			switch {
+			case n.err == errInterrupted:
+				S[d].err = errInterrupted
+			case n.err != nil:
+				S[d].err = errorf("incomplete tree")
+			case entry.sum != nil:
+				S[d].entries = append(S[d].entries, entry)
+			}
+		}
+
+		flush := func(depth int) {
+			for len(S) > 0 && S[len(S)-1].depth >= depth {
+				d := S[len(S)-1]
+				S = S[:len(S)-1]
+				if d.err == nil {
+					d.sum = gitTree(d.entries)
+				}
+
+				entry := gitEntry{gitModeTree, d.filename(), d.sum}
+				if len(d.entries) == 0 {
+					entry.sum = nil // Empty directories are ignored.
+				}
+				add(d.node, entry)
+				output <- d.node
+			}
+		}
+
+		for n := range input {
+			flush(n.depth)
+			if n.err == nil && n.isdir() {
+				S = append(S, &dir{node: n})
+				continue
+			}
+
+			entry := gitEntry{gitModeFile, n.filename(), n.sum}
+			switch {
+			case n.err != nil || !n.isfile():
+			case n.Mode()&os.ModeSymlink != 0:
+				target, err := os.Readlink(n.path)
+				if n.err = err; err == nil {
+					h := creator().(*gitObject).init("blob", int64(len(target)))
+					h.Write([]byte(target))
+					entry.mode, entry.sum = gitModeSymlink, h.Sum(nil)
+				}
+			case !n.isregular():
+				entry.sum = nil // Git can't track other types of files.
+			case n.Mode()&0100 != 0:
+				entry.mode = gitModeExecutable
+			}
+			add(n, entry)
+			output <- n
+		}
+		flush(0)
+		close(output)
+	}()
+	return output
+}
+
+// gitTree() computes the ID of a tree object consisting of the entries. Entries
+// are sorted by their names, but names of trees are compared as if they have a
+// trailing slash.
+func gitTree(entries []gitEntry) []byte {
+	key := func(e gitEntry) string {
+		if e.mode == gitModeTree {
+			return e.name + "/"
+		}
+		return e.name
+	}
+	sort.Slice(entries, func(i, j int) bool { return key(entries[i]) < key(entries[j]) })
+
+	buf := &bytes.Buffer{}
+	for _, e := range entries {
+		fprintf(buf, "%s %s\x00", e.mode, e.name)
+		buf.Write(e.sum)
+	}
+
+	h := creator().(*gitObject).init("tree", int64(buf.Len()))
+	h.Write(buf.Bytes())
+	return h.Sum(nil)
+}
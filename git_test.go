@@ -0,0 +1,100 @@
+// git_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitObject(t *testing.T) {
+	for _, env := range []struct {
+		f      gitFactory
+		data   string
+		result string
+	}{
+		{sha1.New, "", "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391"},
+		{sha1.New, "hello\n", "ce013625030ba8dba906f756967f9e9ca394464a"},
+		{sha256.New, "", "473a0f4c3be8a93681a267e3b1e9a7dcda1185436fe141f7749120a303721813"},
+	} {
+		a := assert.New(t)
+		h := env.f.normalize()()
+
+		// The size is unknown.
+		h.Write([]byte(env.data))
+		a.Equalf(env.result, sprintf("%x", h.Sum(nil)), "%+v", env)
+
+		// The size is known.
+		h.Reset()
+		h.(sizedHash).presize(int64(len(env.data)))
+		h.Write([]byte(env.data))
+		a.Equalf(env.result, sprintf("%x", h.Sum(nil)), "%+v", env)
+	}
+}
+
+func TestTrees(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "git")
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "a"), 0755)
+	os.MkdirAll(filepath.Join(dir, "empty", "empty"), 0755)
+	os.MkdirAll(filepath.Join(dir, ".git", "objects"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "x"), []byte("hi\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "a", "c"), []byte("1\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "a", "run"), []byte("#!/bin/sh\n"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref: refs/heads/master\n"), 0644)
+	if err := os.Symlink("x", filepath.Join(dir, "l")); err != nil {
+		t.Skipf("symbolic links are unsupported: %s", err)
+	}
+
+	defer func() { *_algo, *_all, *_depth = "md5", false, 1 }()
+	*_all, *_depth = true, math.MaxInt32
+
+	for _, env := range []struct {
+		algo   string
+		result map[string]string
+	}{
+		{
+			"git-sha1",
+			map[string]string{
+				".":     "dabb2b7b918e6b721303edc7cf9b3d238b1312b4",
+				"a":     "bb9e44a5a9cebc29da2c534211a0b1933cdb8df8",
+				"x":     "45b983be36b73c0788dc9cbcb76cbb80fc7bb057",
+				"empty": "4b825dc642cb6eb9a060e54bf8d69288fbee4904",
+			},
+		},
+		{
+			"git-sha256",
+			map[string]string{
+				".": "f401e249d29a74d7c08e2348375520680b152eac2d2dad1c8e94351b62db717d",
+			},
+		},
+	} {
+		a := assert.New(t)
+		*_algo, creator = env.algo, factories[env.algo]
+
+		nodes := make(map[string]*node)
+		for n := range trees(queue(digester(make(trigger), walk(make(trigger), []string{dir})))) {
+			rel, _ := filepath.Rel(dir, n.path)
+			nodes[filepath.ToSlash(rel)] = n
+		}
+
+		for name, result := range env.result {
+			if a.NotNilf(nodes[name], "%s %s", env.algo, name) {
+				a.Equalf(result, sprintf("%x", nodes[name].sum), "%s %s", env.algo, name)
+			}
+		}
+		a.Nil(nodes[".git/HEAD"])
+	}
+}
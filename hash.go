@@ -3,13 +3,14 @@
 // Author: blinklv <blinklv@icloud.com>
 // Create Time: 2019-10-23
 // Maintainer: blinklv <blinklv@icloud.com>
-// Last Change: 2019-12-06
+// Last Change: 2026-10-18
 
 // A simple command tool to calculate the digest value of files. It supports some
 // primary Message-Digest Hash algorithms, like MD5, FNV family, and SHA family.
 package main
 
 import (
+	"bytes"
 	"crypto/hmac"
 	"crypto/md5"
 	"crypto/sha1"
@@ -17,20 +18,24 @@ import (
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
 	"hash"
 	"hash/fnv"
 	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 )
 
 /* Global Constants and Variables */
@@ -42,6 +47,13 @@ const binary = "1.0.0" // App binary version.
 // digest to reduce side effects for OS.
 const numDigester = 16
 
+// Number of bytes read from a file at a time when computing its digest.
+const bufferSize = 1 << 20
+
+// The error of a node whose digest computing was interrupted by OS signals.
+// Nodes with this error won't be outputted.
+var errInterrupted = errors.New("interrupted")
+
 // When we call the digester function, we create a new hash.Hash instance to compute
 // the digest of a file. Why don't we use the only one global hash.Hash instance?
 // Because some hash.Hash implementations are not concurrent safe. If there're multiple
@@ -55,9 +67,17 @@ var sumSize int
 // Keyed-Hash Message Authentication Code (HMAC) sign key.
 var hmacKey []byte
 
+// The expected digest specified by the expect option. It's nil when the option
+// is not specified.
+var expected []byte
+
 // Reports whether there is an error when calculating digests.
 var errorExists bool
 
+// Checkpoint journal of the current run. It's nil when the journal option
+// is not specified.
+var jnl *journal
+
 // Standard input, standard output, and standard error file descriptors.
 // The only reason I rename these three variables is simplifying my codes :)
 var stdin, stdout, stderr io.Writer = os.Stdin, os.Stdout, os.Stderr
@@ -78,6 +98,17 @@ var factories = map[string]factory{
 	"fnv64a":     (factory64(fnv.New64a)).normalize(),
 	"fnv128":     fnv.New128,
 	"fnv128a":    fnv.New128a,
+	"git-sha1":   gitFactory(sha1.New).normalize(),
+	"git-sha256": gitFactory(sha256.New).normalize(),
+	"cid":        newUnixFS,
+	"s3etag":     s3Factory(md5.New).normalize(),
+	"dropbox":    newDropbox,
+	"glacier":    newGlacier,
+	"btv2":       newMerkle,
+	"md4":        newMD4,
+	"ed2k":       newEd2k,
+	"tiger":      newTiger,
+	"tth":        newTTH,
 }
 
 // Help document.
@@ -89,6 +120,37 @@ var usages = []string{
 	"\n",
 	"                   md5, sha1, sha224, sha256, sha384, sha512, sha512/224\n",
 	"                   sha512/256, fnv32, fnv32a, fnv64, fnv64a, fnv128, fnv128a\n",
+	"                   git-sha1, git-sha256, cid, s3etag, dropbox, glacier, btv2\n",
+	"                   md4, ed2k, tiger, tth\n",
+	"\n",
+	"                   git-* algorithms compute git object IDs in the SHA-1 or SHA-256 object\n",
+	"                   format, which are blob IDs for files and tree IDs for directories. All\n",
+	"                   files are included recursively except '.git' directories.\n",
+	"\n",
+	"                   cid computes UnixFS CIDv1s of files like 'ipfs add --cid-version=1',\n",
+	"                   files are split into 256 KiB raw leaves linked by a balanced DAG. The\n",
+	"                   default encoding is multibase for it.\n",
+	"\n",
+	"                   s3etag computes ETags of objects uploaded to S3-compatible storage,\n",
+	"                   which are md5 digests of the concatenated md5 digests of parts with\n",
+	"                   the '-N' suffix if there're multiple parts. They're always outputted\n",
+	"                   in hex regardless of the encoding option.\n",
+	"\n",
+	"                   dropbox computes content hashes of Dropbox, which are sha256 digests\n",
+	"                   of the concatenated sha256 digests of 4 MiB blocks. glacier computes\n",
+	"                   tree hashes of Amazon Glacier whose leaves are sha256 digests of 1 MiB\n",
+	"                   chunks.\n",
+	"\n",
+	"                   btv2 computes pieces roots of files in BitTorrent v2, which are roots\n",
+	"                   of merkle trees whose leaves are sha256 digests of 16 KiB blocks.\n",
+	"\n",
+	"                   ed2k computes eDonkey2000 hashes, which are md4 digests of the concatenated\n",
+	"                   md4 digests of 9500 KiB chunks like eMule. tth computes Tiger tree hashes\n",
+	"                   whose leaves are 1024-byte segments, the default encoding is base32 for it.\n",
+	"\n",
+	"       -part_size - the part size of multipart uploads for the s3etag algorithm, like\n",
+	"                   '8M' or '16M'. When verifying against a multipart ETag, the part size\n",
+	"                   is detected from this value and common part sizes. (default: 8M)\n",
 	"\n",
 	"       -filename - control whether to display the corresponded filenames when outputing\n",
 	"                   the digest of files. (default: true)\n",
@@ -105,25 +167,209 @@ var usages = []string{
 	"                       'base64':'standard base64 encoded string'\n",
 	"                          'hex':'hex encoded string'\n",
 	"\n",
+	"       -checkpoint - the directory to save intermediate hash states of large files. States\n",
+	"                   are saved periodically and when the process is interrupted, then the next\n",
+	"                   run resumes from them unless the size or the mtime of a file has changed.\n",
+	"\n",
+	"       -incremental - the directory to save hash states of files at EOF. If a file has\n",
+	"                   only grown since the last run, only new bytes will be hashed, otherwise\n",
+	"                   the whole file will be rehashed. It's useful for append-only files.\n",
+	"\n",
+	"       -archive  - control whether to unpack tar (optionally gzip/bzip2-compressed) and zip\n",
+	"                   archives like directories. Digests of members are outputted with paths\n",
+	"                   like 'archive.tar!path/in/archive'. Hard links in tar archives share digests\n",
+	"                   of their targets. Tar archives compressed by xz ('.tar.xz' or '.txz') are not\n",
+	"                   supported and reported as errors. (default: false)\n",
+	"\n",
+	"       -decompress - control whether to decompress gzip, bzip2 and zlib data before hashing.\n",
+	"                   Formats are detected by magic bytes, and the output of a decompressed\n",
+	"                   file is labelled like 'data.gz (gzip-decompressed)'. (default: false)\n",
+	"\n",
+	"       -sidecar  - the mode of sidecar checksum files like 'foo.iso.sha256', whose extension\n",
+	"                   is the name of the hash algorithm. Its values can be 'write' or 'verify'.\n",
+	"                   In the write mode, a sidecar file will be written next to each hashed\n",
+	"                   file. In the verify mode, files with sidecar files will be checked and\n",
+	"                   outputted like 'foo.iso: OK' or 'foo.iso: FAILED'. Sidecar files are\n",
+	"                   never hashed in both modes. The write mode can't be used with the decompress\n",
+	"                   option. (default: '', no sidecar files)\n",
+	"\n",
+	"       -manifest - the mode of per-directory manifest files like 'SHA256SUMS', whose name\n",
+	"                   is determined by the hash algorithm. Its values can be 'write' or 'verify'.\n",
+	"                   In the write mode, a manifest file listing digests of files directly in\n",
+	"                   the directory will be written into each directory, so subtrees can be\n",
+	"                   copied and verified independently. In the verify mode, files listed in\n",
+	"                   manifest files will be checked like the sidecar option, and missing\n",
+	"                   files will be reported. The write mode can't be used with the decompress\n",
+	"                   option. (default: '', no manifest files)\n",
+	"\n",
+	"       -xattr    - the mode of storing digests in extended attributes, which follows the\n",
+	"                   shatag convention 'user.shatag.<algo>'. The mtime and the size of files\n",
+	"                   are stored in 'user.shatag.ts' and 'user.shatag.size'. Only supported on\n",
+	"                   Linux. Its values can be one in the following list:\n",
+	"\n",
+	"                    'write':'store digests to extended attributes'\n",
+	"                   'verify':'check files against digests in extended attributes'\n",
+	"                    'cache':'reuse digests in extended attributes if files are unchanged'\n",
+	"\n",
+	"                   (default: '', no extended attributes)\n",
+	"\n",
+	"       -dirhash  - control whether to compute the 'h1:' hash recorded in go.sum files for\n",
+	"                   each directory or zip file, which is compatible with the Hash1 algorithm\n",
+	"                   of golang.org/x/mod/sumdb/dirhash. All files in a directory are included\n",
+	"                   recursively, and the hash algorithm is always sha256. (default: false)\n",
+	"\n",
+	"       -dirhash_prefix - the prefix of file names in a directory for the dirhash option,\n",
+	"                   which is usually 'module@version' like 'golang.org/x/text@v0.3.0'.\n",
+	"                   It's ignored for zip files. (default: '')\n",
+	"\n",
+	"       -encoding - the encoding of digests, which is applied to all outputs including\n",
+	"                   sidecar and manifest files, and is accepted when parsing them. Its\n",
+	"                   values can be one in the following list:\n",
+	"\n",
+	"                         'hex':'lowercase hex encoded string'\n",
+	"                         'oci':'algorithm-prefixed hex encoded string like 'sha256:<hex>''\n",
+	"                         'sri':'Subresource Integrity string like 'sha384-<base64>''\n",
+	"                      'base64':'standard base64 encoded string'\n",
+	"                   'base64url':'URL-safe base64 encoded string without padding'\n",
+	"                      'base32':'standard base32 encoded string without padding'\n",
+	"                      'base58':'base58 encoded string in the Bitcoin alphabet'\n",
+	"                   'multihash':'base58 encoded multihash like 'Qm<base58>' for sha256'\n",
+	"                   'multibase':'lowercase base32 multibase string like 'b<base32>''\n",
+	"                       'nix32':'base32 encoded string in the Nix alphabet and bit order'\n",
+	"\n",
+	"                   The sri encoding only supports sha256, sha384 and sha512. (default: hex)\n",
+	"\n",
+	"       -oci_layout - control whether to verify OCI image layout directories. Digests and\n",
+	"                   sizes of all blobs referenced by 'index.json' are checked against their\n",
+	"                   descriptors recursively, and the results are outputted like the sidecar\n",
+	"                   option. The default hash algorithm is sha256 for it. (default: false)\n",
+	"\n",
+	"       -torrent  - the path of a torrent file to verify roots against. The root should\n",
+	"                   be the file of a single-file torrent, or the directory containing\n",
+	"                   files of a multi-file torrent. In BitTorrent v1, files are read\n",
+	"                   sequentially and bad pieces are reported for each file. Pieces\n",
+	"                   roots of files are verified if the torrent supports BitTorrent v2.\n",
+	"                   Results are outputted like the sidecar option.\n",
+	"\n",
+	"       -torrent_create - the path of a torrent file to create from the root, which is\n",
+	"                   a file or a directory including all files in it. The info hash of\n",
+	"                   the torrent is outputted.\n",
+	"\n",
+	"       -torrent_version - the version of created torrents. Its values can be 'v1', 'v2'\n",
+	"                   or 'hybrid'. (default: v1)\n",
+	"\n",
+	"       -piece_length - the piece length of created torrents, which should be a power\n",
+	"                   of two and not less than 16K. (default: 256K)\n",
+	"\n",
+	"       -ed2k_link - control whether to output ed2k links of files like\n",
+	"                   'ed2k://|file|name|size|hash|/' instead of digests. It can only be\n",
+	"                   used with the ed2k algorithm. (default: false)\n",
+	"\n",
+	"       -nar      - control whether to compute the hash of the Nix Archive (NAR) of each\n",
+	"                   root like 'nix-hash --type sha256 --base32'. Directories, symlinks and\n",
+	"                   executable bits are serialized, and all files are included recursively.\n",
+	"                   The hash algorithm can be md5, sha1, sha256 or sha512, and the default\n",
+	"                   ones are sha256 and the nix32 encoding for it. (default: false)\n",
+	"\n",
+	"       -journal  - the path of a checkpoint journal file. Completed results will be recorded\n",
+	"                   in it, so a run interrupted by SIGINT or SIGTERM can resume from where it\n",
+	"                   stopped when re-running with the same arguments. The journal file will be\n",
+	"                   removed after the run has done.\n",
+	"\n",
+	"       -progress - control whether to display a live progress line on the stderr. It's\n",
+	"                   suppressed when the stderr is not a terminal. (default: false)\n",
+	"                   NOTE: Send SIGUSR1 to the process can output a status snapshot at\n",
+	"                   any time whether this option is specified or not.\n",
+	"\n",
+	"       -reader   - the backend for reading files. (default: read)\n",
+	"                   Its values can be one in the following list:\n",
+	"\n",
+	"                       'read':'plain buffered reads'\n",
+	"                       'mmap':'map files into the memory'\n",
+	"                     'direct':'O_DIRECT reads which bypass the page cache'\n",
+	"                   'io_uring':'batched reads submitted to io_uring'\n",
+	"\n",
+	"                   All backends except 'read' are only supported on Linux, and it falls\n",
+	"                   back to plain reads automatically where a backend is unsupported. A file\n",
+	"                   truncated while it's being read by 'mmap' is reported as an error.\n",
+	"\n",
+	"       -sparse   - control whether to display the allocated size and the apparent size of\n",
+	"                   files. Holes of sparse files are never read from the disk on Linux\n",
+	"                   whether this option is specified or not. (default: false)\n",
+	"\n",
+	"       -rate     - limit the number of bytes read per second, like '512K', '50M' or '1G'.\n",
+	"                   The limit is shared by all files read concurrently. (default: no limit)\n",
+	"\n",
+	"       -iops     - limit the number of read operations per second. (default: 0, no limit)\n",
+	"\n",
+	"       -ionice   - set the I/O scheduling class of the process to idle, so it only gets\n",
+	"                   disk time when no other program has asked for disk I/O. Only supported\n",
+	"                   on Linux. (default: false)\n",
+	"\n",
+	"       -nocache  - advise the kernel to drop the read data from the page cache, so scans\n",
+	"                   don't evict hot pages. Only takes effect on Linux. (default: false)\n",
+	"\n",
+	"       -summary  - output the summary statistics of the run to the stderr when it has done.\n",
+	"                   Its values can be 'text' or 'json'. (default: '', no summary)\n",
+	"\n",
+	"       -timeout  - the timeout of fetching remote files. A request will be canceled if\n",
+	"                   connecting, receiving the response header or any read of the body can't\n",
+	"                   be done within it. (default: 30s)\n",
+	"\n",
+	"       -expect   - the expected digest in the selected encoding ('h1:' hash in the dirhash mode).\n",
+	"                   Files whose digests are different from it will be reported as errors.\n",
+	"                   (default: '', no comparison)\n",
+	"\n",
 	"       -version  - control whether to display version information. (default: false)\n",
 	"\n",
 	"       -help     - control whether to display usage information. (defualt: false)\n",
 	"\n",
 	"       file      - the objective file of the hash algorithm. If its type is directory,\n",
 	"                   computing digests of all files in this directory recursively. This\n",
-	"                   tool will read from the stdin when no file specified.\n",
+	"                   tool will read from the stdin when no file specified. A file can also\n",
+	"                   be a http://, https:// or file:// URL, remote files are streamed into\n",
+	"                   the hash without being saved to the disk.\n",
 	"\n",
 }
 
 // Command-Line options.
 var (
-	_algo     = flag.String("algo", "md5", "")
-	_filename = flag.Bool("filename", true, "")
-	_depth    = flag.Int("depth", 1, "")
-	_all      = flag.Bool("all", false, "")
-	_hmac_key = flag.String("hmac_key", "", "")
-	_version  = flag.Bool("version", false, "")
-	_help     = flag.Bool("help", false, "")
+	_algo            = flag.String("algo", "md5", "")
+	_filename        = flag.Bool("filename", true, "")
+	_depth           = flag.Int("depth", 1, "")
+	_all             = flag.Bool("all", false, "")
+	_hmac_key        = flag.String("hmac_key", "", "")
+	_archive         = flag.Bool("archive", false, "")
+	_decompress      = flag.Bool("decompress", false, "")
+	_sidecar         = flag.String("sidecar", "", "")
+	_manifest        = flag.String("manifest", "", "")
+	_xattr           = flag.String("xattr", "", "")
+	_dirhash         = flag.Bool("dirhash", false, "")
+	_dirhash_prefix  = flag.String("dirhash_prefix", "", "")
+	_encoding        = flag.String("encoding", "hex", "")
+	_oci_layout      = flag.Bool("oci_layout", false, "")
+	_part_size       = flag.String("part_size", "8M", "")
+	_torrent         = flag.String("torrent", "", "")
+	_torrent_create  = flag.String("torrent_create", "", "")
+	_torrent_version = flag.String("torrent_version", "v1", "")
+	_piece_length    = flag.String("piece_length", "256K", "")
+	_ed2k_link       = flag.Bool("ed2k_link", false, "")
+	_nar             = flag.Bool("nar", false, "")
+	_journal         = flag.String("journal", "", "")
+	_checkpoint      = flag.String("checkpoint", "", "")
+	_incremental     = flag.String("incremental", "", "")
+	_progress        = flag.Bool("progress", false, "")
+	_reader          = flag.String("reader", "read", "")
+	_sparse          = flag.Bool("sparse", false, "")
+	_rate            = flag.String("rate", "", "")
+	_iops            = flag.Int("iops", 0, "")
+	_ionice          = flag.Bool("ionice", false, "")
+	_nocache         = flag.Bool("nocache", false, "")
+	_summary         = flag.String("summary", "", "")
+	_timeout         = flag.Duration("timeout", 30*time.Second, "")
+	_expect          = flag.String("expect", "", "")
+	_version         = flag.Bool("version", false, "")
+	_help            = flag.Bool("help", false, "")
 )
 
 /* Main Functions */
@@ -132,28 +378,46 @@ func main() {
 	var (
 		exit, done = make(trigger), make(trigger)
 		signals    = make(chan os.Signal, 8)
+		status     = make(chan os.Signal, 8)
 	)
 
 	go func() {
-		display(queue(digester(walk(exit, parse_arg()))))
+		display(jnl.record(manifests(sidecars(tags(dirhashes(trees(nars(exit, torrents(exit, queue(digester(exit, walk(exit, parse_arg()))))))))))))
 		close(done)
 	}()
 
+	// Some OS signals (SIGUSR1) only make the process output the current
+	// status, they won't affect the process.
+	if len(statusSignals) > 0 {
+		signal.Notify(status, statusSignals...)
+	}
+
 	// There're two cases will cause the process exit. The first case
 	// is trival, computing digests of all files has done. The second
 	// case is triggered by some OS signals, it will make the process
 	// exits ahead of time.
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
-	select {
-	case <-signals:
-		close(exit)
-		<-done
-	case <-done:
-		if errorExists {
-			os.Exit(1)
+	for {
+		select {
+		case <-status:
+			meter.snapshot()
+			continue
+		case <-signals:
+			close(exit)
+			<-done
+			meter.close()
+			jnl.close(false) // Keep the journal for the next run.
+			summarize()
+		case <-done:
+			meter.close()
+			jnl.close(true)
+			summarize()
+			if errorExists {
+				os.Exit(1)
+			}
 		}
+		return
 	}
-	return
 }
 
 // parse_arg() parses the command arguments and returns root files.
@@ -171,6 +435,148 @@ func parse_arg() []string {
 		exit(nil)
 	}
 
+	// The dirhash option always uses sha256 and includes all files.
+	if *_dirhash {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "algo" && *_algo != "sha256" {
+				exit(errorf("the dirhash option only supports the sha256 algorithm"))
+			}
+		})
+
+		if *_hmac_key != "" || *_decompress {
+			exit(errorf("the dirhash option can't be used with the hmac_key or decompress option"))
+		}
+		*_algo, *_all, *_depth = "sha256", true, math.MaxInt32
+	}
+
+	// Git tree IDs always include all files.
+	if isgit() {
+		if *_hmac_key != "" {
+			exit(errorf("the hmac_key option can't be used with git object IDs"))
+		}
+		*_all, *_depth = true, math.MaxInt32
+	}
+
+	// The default hash algorithm of OCI image layouts is sha256.
+	if *_oci_layout {
+		algo := "sha256"
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "algo" {
+				algo = *_algo
+			}
+		})
+		*_algo = algo
+	}
+
+	// Torrents determine hash algorithms and include all files.
+	if *_torrent != "" || *_torrent_create != "" {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "algo" {
+				exit(errorf("the torrent options can't be used with the algo option"))
+			}
+		})
+
+		if *_hmac_key != "" || *_archive || *_decompress || *_dirhash || *_oci_layout ||
+			*_sidecar != "" || *_manifest != "" || *_xattr != "" || *_journal != "" {
+			exit(errorf("the torrent options can't be used with other checksum or archive options"))
+		} else if *_torrent != "" && *_torrent_create != "" {
+			exit(errorf("the torrent option and the torrent_create option are exclusive"))
+		}
+
+		var err error
+		if pieceLength, err = parseSize(*_piece_length); err != nil || pieceLength < btBlockSize || pieceLength&(pieceLength-1) != 0 {
+			exit(errorf("invalid piece length '%s'", *_piece_length))
+		}
+
+		if *_torrent != "" {
+			if meta, err = loadTorrent(*_torrent); err != nil {
+				exit(errorf("load torrent failed: %s", err))
+			} else if flag.NArg() == 0 {
+				exit(errorf("no file or directory to verify"))
+			}
+
+			*_algo, *_all = "sha1", true
+			if meta.v2 {
+				*_algo = "btv2"
+			}
+		} else {
+			switch {
+			case *_torrent_version != "v1" && *_torrent_version != "v2" && *_torrent_version != "hybrid":
+				exit(errorf("unknown torrent version '%s'", *_torrent_version))
+			case flag.NArg() != 1:
+				exit(errorf("a torrent should be created from one file or directory"))
+			}
+
+			*_algo, *_depth = "sha1", math.MaxInt32
+			if *_torrent_version == "v2" {
+				*_algo = "sha256"
+			}
+		}
+	}
+
+	// NARs include all files, and they're hashed with sha256 and encoded in
+	// nix32 by default like nix-hash.
+	if *_nar {
+		algo, encoding := "sha256", "nix32"
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "algo":
+				algo = *_algo
+			case "encoding":
+				encoding = *_encoding
+			}
+		})
+
+		switch {
+		case !narAlgos[algo]:
+			exit(errorf("the nar option doesn't support the '%s' algorithm", algo))
+		case *_hmac_key != "" || *_archive || *_decompress || *_dirhash || *_oci_layout || *_sidecar != "" ||
+			*_manifest != "" || *_xattr != "" || *_journal != "" || *_torrent != "" || *_torrent_create != "":
+			exit(errorf("the nar option can't be used with other checksum or archive options"))
+		case flag.NArg() == 0:
+			exit(errorf("no file or directory to serialize"))
+		}
+		*_algo, *_encoding, *_all, *_depth = algo, encoding, true, math.MaxInt32
+	}
+
+	// The default encoding of CIDs is multibase, and TTHs are usually encoded
+	// in base32.
+	if encoding, ok := map[string]string{"cid": "multibase", "tth": "base32"}[*_algo]; ok {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "encoding" {
+				encoding = *_encoding
+			}
+		})
+
+		if *_algo == "cid" && *_hmac_key != "" {
+			exit(errorf("the hmac_key option can't be used with CIDs"))
+		}
+		*_encoding = encoding
+	}
+
+	if *_ed2k_link && *_algo != "ed2k" {
+		exit(errorf("the ed2k_link option can only be used with the ed2k algorithm"))
+	} else if *_ed2k_link && (*_hmac_key != "" || *_decompress) {
+		exit(errorf("the ed2k_link option can't be used with the hmac_key or decompress option"))
+	}
+
+	if _, ok := encodings[*_encoding]; !ok {
+		exit(errorf("unknown encoding '%s'", *_encoding))
+	} else if _, ok = multihashCodes[*_algo]; !ok && *_encoding == "multihash" {
+		exit(errorf("the multihash encoding doesn't support the '%s' algorithm", *_algo))
+	} else if !sriAlgos[*_algo] && *_encoding == "sri" {
+		exit(errorf("the sri encoding doesn't support the '%s' algorithm", *_algo))
+	}
+
+	if *_algo == "s3etag" {
+		var err error
+		if partSize, err = parseSize(*_part_size); err != nil || partSize <= 0 {
+			exit(errorf("invalid part size '%s'", *_part_size))
+		} else if *_hmac_key != "" {
+			exit(errorf("the hmac_key option can't be used with S3 ETags"))
+		}
+	}
+
 	if creator = factories[*_algo]; creator == nil {
 		exit(errorf("unknown hash algorithm '%s'", *_algo))
 	}
@@ -188,7 +594,91 @@ func parse_arg() []string {
 		creator = factoryHMAC(creator).normalize()
 	}
 
-	return flag.Args() // Root files to be processed.
+	if *_expect != "" {
+		var err error
+		if expected, err = decodeDigest(*_expect); err != nil || len(expected) != sumSize {
+			exit(errorf("invalid expected digest '%s'", *_expect))
+		}
+	}
+
+	if *_sidecar != "" && *_sidecar != "write" && *_sidecar != "verify" {
+		exit(errorf("unknown sidecar mode '%s'", *_sidecar))
+	} else if *_sidecar == "write" && *_decompress {
+		exit(errorf("the write mode of the sidecar option can't be used with the decompress option"))
+	}
+	sidecarExt = "." + algoName()
+
+	if *_manifest != "" && *_manifest != "write" && *_manifest != "verify" {
+		exit(errorf("unknown manifest mode '%s'", *_manifest))
+	} else if *_manifest != "" && *_sidecar != "" {
+		exit(errorf("the manifest option and the sidecar option are exclusive"))
+	} else if *_manifest == "write" && *_decompress {
+		exit(errorf("the write mode of the manifest option can't be used with the decompress option"))
+	}
+	manifestName = strings.ToUpper(algoName()) + "SUMS"
+
+	if *_xattr != "" && *_xattr != "write" && *_xattr != "verify" && *_xattr != "cache" {
+		exit(errorf("unknown xattr mode '%s'", *_xattr))
+	} else if *_xattr != "" && (*_hmac_key != "" || *_decompress) {
+		exit(errorf("the xattr option can't be used with the hmac_key or decompress option"))
+	}
+	xattrName = xattrPrefix + algoName()
+
+	if *_checkpoint != "" {
+		if err := os.MkdirAll(*_checkpoint, 0755); err != nil {
+			exit(errorf("create checkpoint directory failed: %s", err))
+		}
+		checkpoints = stateStore(*_checkpoint)
+	}
+
+	if *_incremental != "" {
+		if err := os.MkdirAll(*_incremental, 0755); err != nil {
+			exit(errorf("create incremental directory failed: %s", err))
+		}
+		increments = stateStore(*_incremental)
+	}
+
+	if *_journal != "" {
+		var err error
+		if jnl, err = (&journal{}).open(*_journal, os.Args[1:]); err != nil {
+			exit(errorf("open journal failed: %s", err))
+		}
+	}
+
+	if *_rate != "" {
+		rate, err := parseSize(*_rate)
+		if err != nil || rate <= 0 {
+			exit(errorf("invalid rate '%s'", *_rate))
+		}
+		bandwidth = (&bucket{}).init(float64(rate))
+	}
+
+	if *_iops < 0 {
+		exit(errorf("invalid iops '%d'", *_iops))
+	} else if *_iops > 0 {
+		iops = (&bucket{}).init(float64(*_iops))
+	}
+
+	if *_ionice {
+		if err := ionice(); err != nil {
+			exit(errorf("set I/O scheduling class failed: %s", err))
+		}
+	}
+
+	if _, ok := backends[*_reader]; !ok {
+		exit(errorf("unknown reader '%s'", *_reader))
+	}
+	opener = backends[*_reader]
+
+	if *_summary != "" && *_summary != "text" && *_summary != "json" {
+		exit(errorf("unknown summary format '%s'", *_summary))
+	}
+
+	roots := flag.Args() // Root files to be processed.
+	if *_progress && isTerminal(os.Stderr) {
+		meter.run(roots)
+	}
+	return roots
 }
 
 // walk() traverses a directory tree in pre-order and push nodes to the output channel.
@@ -205,22 +695,78 @@ func walk(exit trigger, roots []string) (output chan *node) {
 		}
 
 		var (
-			top *node
-			i   int // Walk sequence.
+			top   *node
+			i     int // Walk sequence.
+			links = make(map[fileID]*node)
 		)
 
 		for len(S) > 0 {
 			top, S = S[len(S)-1], S[:len(S)-1]
 			if !(*_all) && isHidden(top.filename()) {
+				report.skip()
+				continue
+			}
+
+			// Git repository directories are not included in git trees.
+			if isgit() && top.depth > 0 && top.isdir() && top.filename() == ".git" {
+				report.ignore()
+				continue
+			}
+
+			// Sidecar files are never hashed, and the expected digests of
+			// files are loaded from them in the verify mode.
+			if *_sidecar != "" && top.isfile() && top.isregular() {
+				if top = top.sidecar(); top == nil {
+					report.ignore()
+					continue
+				}
+			}
+
+			// Digests of files are loaded from extended attributes in the
+			// verify mode and the cache mode.
+			if (*_xattr == "verify" || *_xattr == "cache") && top.isfile() && top.isregular() {
+				if top = top.xattr(); top == nil {
+					report.ignore()
+					continue
+				}
+			}
+
+			// Manifest files are never hashed too.
+			if *_manifest != "" && top.isfile() && top.filename() == manifestName {
+				report.ignore()
 				continue
 			}
 
 			if top.err == nil && top.depth < *_depth {
-				if children := top.children(); len(children) > 0 {
+				if top.isdir() {
+					report.visit()
+				}
+
+				children := top.children()
+				if (*_archive || *_dirhash && top.depth == 0) && top.isregular() {
+					top.archive = true // Archives are unpacked by digesters like directories.
+				} else if *_manifest == "verify" && top.isdir() && top.err == nil {
+					children = top.manifest(children)
+				} else if *_oci_layout && top.depth == 0 && top.isdir() && top.err == nil {
+					children = top.layout()
+				} else if meta != nil && top.depth == 0 {
+					children = top.torrent()
+				}
+				if len(children) > 0 {
 					S = append(S, children...)
 				}
 			}
 
+			// Hard links to the same inode only need to be read once, the
+			// digest computed for the first path will be reused.
+			if top.err == nil && top.FileInfo != nil && top.isregular() && !top.archive {
+				if id, ok := inode(top.FileInfo); ok && links[id] != nil {
+					top.link = links[id]
+				} else if ok {
+					top.done, links[id] = make(trigger), top
+				}
+			}
+
 			select {
 			case output <- top.mark(i):
 				i++
@@ -235,8 +781,10 @@ func walk(exit trigger, roots []string) (output chan *node) {
 }
 
 // digester() gets the file information from the input channel and computes
-// their digests, then pushes the results to the output channel.
-func digester(input chan *node) (output chan *node) {
+// their digests, then pushes the results to the output channel. Archives are
+// unpacked here, so members are hashed concurrently with other files. Computing
+// digests of large files will be interrupted if the 'exit' parameter triggers.
+func digester(exit trigger, input chan *node) (output chan *node) {
 	output = make(chan *node, numDigester)
 	go func() {
 		crun(numDigester, func() {
@@ -244,12 +792,24 @@ func digester(input chan *node) (output chan *node) {
 			// safe, so we need to create a new one for each goroutine.
 			h := creator()
 			for n := range input {
-				if n.err == nil && n.isregular() {
+				start := time.Now()
+				if r := jnl.lookup(n); r != nil {
+					n.sum, n.codec, n.resumed = r.sum, r.codec, true // Computed by the previous run.
+				} else if n.link != nil {
+					<-n.link.done
+					n.sum, n.err, n.codec = n.link.sum, n.link.err, n.link.codec
+				} else if n.archive && n.unpack(exit, h) {
+					// Digests of members are computed when unpacking.
+				} else if n.err == nil && n.isregular() && n.container == "" && !n.tagged && !pieced() && !(*_nar) {
 					h.Reset() // Key step!
-
-					data, err := n.read()
-					h.Write(data)
-					n.sum, n.err = h.Sum(nil), err
+					n.sum, n.err = n.digest(exit, h)
+				}
+				if n.counted() && !pieced() && !(*_nar) {
+					meter.done() // Files are read by later stages in these modes.
+				}
+				n.settle(start)
+				if n.done != nil {
+					close(n.done) // Wake up other hard links.
 				}
 				output <- n
 			}
@@ -259,7 +819,8 @@ func digester(input chan *node) (output chan *node) {
 	return output
 }
 
-// queue() will sort results by walking sequence and output them.
+// queue() will sort results by walking sequence and output them. Members of
+// an unpacked archive are outputted right after the archive.
 func queue(input chan *node) (output chan *node) {
 	output = make(chan *node)
 	go func() {
@@ -273,6 +834,9 @@ func queue(input chan *node) (output chan *node) {
 			for n = cache[next]; n != nil; n = cache[next] {
 				delete(cache, next)
 				output <- n
+				for _, m := range n.members {
+					output <- m // Members of an archive follow it.
+				}
 				next++
 			}
 		}
@@ -285,15 +849,24 @@ func queue(input chan *node) (output chan *node) {
 func display(input chan *node) {
 	for n := range input {
 		switch {
+		case n.err == errInterrupted:
 		case n.err != nil:
 			errorExists = true
 			fallthrough
-		case n.isregular():
-			fprintf(stdout, "%s\n", n)
+		case n.isregular(), (n.isdir() || *_nar) && n.sum != nil: // A NAR can be a symlink.
+			meter.printf(stdout, "%s\n", n)
 		}
 	}
 }
 
+// summarize() outputs the summary of the current run to the standard error
+// if the summary option is specified.
+func summarize() {
+	if *_summary != "" {
+		report.output(stderr, *_summary)
+	}
+}
+
 // version() outputs the version information to the w io.Writer.
 func version(w io.Writer) {
 	fprintf(w, "%s v%s (built w/%s)\n", "go-hash", binary, runtime.Version())
@@ -329,6 +902,24 @@ type node struct {
 	depth int    // Directory depth
 	sum   []byte // Digest
 	err   error
+	link  *node   // The first node which is a hard link to the same inode
+	done  trigger // Closed after the digest of a hard-linked node is computed
+
+	container string                        // Path of the archive containing the node
+	archive   bool                          // Reports whether the node may be an archive to unpack
+	packed    bool                          // Reports whether the node is an unpacked archive
+	members   []*node                       // Members of the unpacked archive
+	source    func() (io.ReadCloser, error) // Opens an archive member or a remote file
+	codec     string                        // Compression format of the hashed data
+	want      []byte                        // Expected digest recorded in a checksum file
+	tagged    bool                          // Reports whether the digest is loaded from xattrs
+	resumed   bool                          // Reports whether the digest is restored from the journal
+}
+
+// Identity of a file on a device.
+type fileID struct {
+	dev uint64 // Device number
+	ino uint64 // Inode number
 }
 
 // init() inits a node instance by using its path and returns itself.
@@ -363,15 +954,23 @@ func (n *node) children() []*node {
 func (n *node) nodes(names []string) []*node {
 	var ns = make([]*node, 0, len(names))
 	for _, name := range rsort(names) {
-		ns = append(ns, (&node{
-			depth: n.depth + 1,
-		}).init(filepath.Join(n.path, name)))
+		m := &node{depth: n.depth + 1}
+		if n.depth < 0 && isURL(name) {
+			ns = append(ns, m.remote(name)) // Only root files can be URLs.
+		} else {
+			ns = append(ns, m.init(filepath.Join(n.path, name)))
+		}
 	}
 	return ns
 }
 
-// isregular() checks whether the node describes a regular file.
+// isregular() checks whether the node describes a regular file. An unpacked
+// archive is treated as a directory.
 func (n *node) isregular() bool {
+	if n.packed {
+		return false
+	}
+
 	if n.FileInfo != nil {
 		return n.Mode().IsRegular()
 	}
@@ -398,13 +997,137 @@ func (n *node) filename() string {
 	return filepath.Base(n._path() /* not path */)
 }
 
-// read() reads from the file or the standard input until an error or EOF
-// and returns the data it read.
-func (n *node) read() ([]byte, error) {
-	if n.path != "" {
-		return ioutil.ReadFile(n.path)
+// isfile() checks whether the node describes a file on the file system, which
+// means it's neither the standard input nor an archive member.
+func (n *node) isfile() bool {
+	return n.path != "" && n.FileInfo != nil && n.container == ""
+}
+
+// size() returns the size of the file. It returns 0 for the standard input.
+func (n *node) size() int64 {
+	if n.FileInfo != nil {
+		return n.Size()
 	}
-	return ioutil.ReadAll(os.Stdin)
+	return 0
+}
+
+// open() opens the file, the archive member or the standard input for reading.
+// The data will be decompressed if the decompress option is specified and its
+// compression format is detected, the format is stored to the codec field.
+func (n *node) open() (r io.ReadCloser, err error) {
+	switch {
+	case n.source != nil:
+		r, err = n.source()
+	case n.path != "":
+		r, err = openFile(n.path)
+	default:
+		r = ioutil.NopCloser(os.Stdin)
+	}
+
+	if err != nil || !(*_decompress) {
+		return r, err
+	}
+
+	var dr io.ReadCloser
+	if dr, n.codec, err = decompress(r); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return dr, nil
+}
+
+// digest() reads from the file or the standard input until an error or EOF
+// and returns the digest computed by the h hash.Hash. The intermediate hash
+// state of a large file will be saved periodically if the checkpoint option
+// is specified, it's also saved when the 'exit' parameter triggers. The hash
+// state at EOF will be saved if the incremental option is specified.
+func (n *node) digest(exit trigger, h hash.Hash) ([]byte, error) {
+	r, err := n.open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	// The size of the data is known unless it's decompressed or it's read
+	// from the standard input or a remote file.
+	if sh, ok := h.(sizedHash); ok && n.FileInfo != nil && n.codec == "" {
+		sh.presize(n.Size())
+	}
+
+	// Parameters of some hash algorithms are detected from the expected digest.
+	if d, ok := h.(detector); ok && n.FileInfo != nil && n.codec == "" {
+		want := n.want
+		if want == nil {
+			want = expected
+		}
+		d.detect(n.Size(), want)
+	}
+
+	// Only new bytes appended to the file need to be hashed if the incremental
+	// state is restored, otherwise try to resume from the checkpoint.
+	var (
+		inc    = increments.increment(n, h, r)
+		c      *checkpoint
+		offset = inc.offset()
+	)
+
+	if offset == 0 {
+		c = checkpoints.checkpoint(n, h)
+		c.seek(r, h)
+		offset = c.offset()
+	}
+
+	var (
+		buf = make([]byte, bufferSize)
+		nr  int
+	)
+
+	for err == nil {
+		select {
+		case <-exit:
+			c.update(offset, true)
+			return nil, errInterrupted
+		default:
+		}
+
+		iops.take(exit, 1)
+		nr, err = r.Read(buf)
+		bandwidth.take(exit, nr)
+		dropCache(r, offset, int64(nr))
+
+		h.Write(buf[:nr])
+		meter.read(nr)
+		offset += int64(nr)
+		c.update(offset, false)
+	}
+
+	if err != io.EOF {
+		return nil, err
+	}
+
+	c.done()
+	inc.update(n, offset)
+	return h.Sum(nil), nil
+}
+
+// settle() verifies the digest of the node and adds the result to the summary.
+// The start parameter is the time when processing the node began.
+func (n *node) settle(start time.Time) {
+	if n.err == nil && n.sum != nil && !(*_dirhash) {
+		n.err = n.verify() // Hashes of roots are verified in the dirhash mode.
+	}
+	report.add(n, time.Since(start))
+}
+
+// verify() compares the digest with the expected digest recorded in a checksum
+// file or specified by the expect option, returns an error when they're different.
+func (n *node) verify() error {
+	if n.want != nil && !hmac.Equal(n.sum, n.want) {
+		return errMismatch
+	} else if n.want == nil && expected != nil && !hmac.Equal(n.sum, expected) {
+		return errorf("digest mismatch, expected %s", (&node{sum: expected}).encode())
+	}
+	return nil
 }
 
 // _path() returns "-" instead of an empty string when the path is empty.
@@ -415,19 +1138,51 @@ func (n *node) _path() string {
 	return "-" // Represents the standard input (stdin).
 }
 
+// encode() encodes the digest of the node for outputting.
+func (n *node) encode() string {
+	return encodeDigest(n.sum)
+}
+
+// label() returns the compression format of the data when it's decompressed
+// before hashing, otherwise returns an empty string.
+func (n *node) label() string {
+	if n.codec != "" {
+		return sprintf(" (%s-decompressed)", n.codec)
+	}
+	return ""
+}
+
+// sizes() returns the allocated size and the apparent size of the file when
+// the sparse option is specified, otherwise returns an empty string.
+func (n *node) sizes() string {
+	if *_sparse && n.FileInfo != nil {
+		if size, ok := allocated(n.FileInfo); ok {
+			return sprintf("  (allocated: %d, apparent: %d)", size, n.Size())
+		}
+	}
+	return ""
+}
+
 // String() returns the string form of the node.
 func (n *node) String() string {
-	if n.err == nil && *_filename {
-		return sprintf("%x  %s", n.sum, n._path())
+	if n.want != nil && n.err == nil {
+		return sprintf("%s: OK", n._path())
+	} else if n.want != nil && n.err == errMismatch {
+		return sprintf("%s: FAILED", n._path())
+	} else if n.err == nil && *_ed2k_link && n.FileInfo != nil {
+		return n.ed2kLink()
+	} else if n.err == nil && *_filename {
+		return sprintf("%s  %s%s%s", n.encode(), n._path(), n.label(), n.sizes())
 	} else if n.err == nil && !(*_filename) {
-		return sprintf("%x", n.sum)
+		return sprintf("%s%s%s", n.encode(), n.label(), n.sizes())
 	} else {
-		lines := split(sprintf("ERROR: %s", n.err), 2*sumSize)
+		width := len(encodeDigest(bytes.Repeat([]byte{0xff}, sumSize))) // The longest one.
+		lines := split(sprintf("ERROR: %s", n.err), width)
 
 		// Pads the last line with extra blank spaces and appends the file
 		// name to the first line. NOTE: The order of these two operations
 		// can't be exchanged.
-		lines[len(lines)-1] = pad(lines[len(lines)-1], 2*sumSize)
+		lines[len(lines)-1] = pad(lines[len(lines)-1], width)
 		lines[0] = sprintf("%s  %s", lines[0], n._path())
 		return strings.Join(lines, "\n")
 	}
@@ -496,6 +1251,35 @@ func (key *secretKey) decode() ([]byte, error) {
 // The only reason I rename the following functions is simplifying my codes :)
 var sprintf, errorf, fprintf = fmt.Sprintf, fmt.Errorf, fmt.Fprintf
 
+// parseSize() parses a size string like '512K', '8M' or '1G'. The units are
+// powers of 1024 and a size without unit means bytes.
+func parseSize(str string) (int64, error) {
+	var (
+		shift uint
+		units = "KMGTPE"
+	)
+
+	if n := len(str); n > 0 {
+		if i := strings.IndexByte(units, str[n-1]&^0x20 /* upper */); i >= 0 {
+			str, shift = str[:n-1], 10*uint(i+1)
+		}
+	}
+
+	size, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, err
+	} else if size > math.MaxInt64>>shift || size < math.MinInt64>>shift {
+		return 0, errorf("size is out of range")
+	}
+	return size << shift, nil
+}
+
+// algoName() returns the name of the hash algorithm which can be used in file
+// names, like 'sha256' or 'sha512-256'.
+func algoName() string {
+	return strings.Replace(*_algo, "/", "-", -1)
+}
+
 // rsort() (Reverse Sort) sorts a slice of strings in decreasing alphabetical order.
 func rsort(strs []string) []string {
 	sort.Sort(sort.Reverse(sort.StringSlice(strs)))
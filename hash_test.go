@@ -3,17 +3,20 @@
 // Author: blinklv <blinklv@icloud.com>
 // Create Time: 2019-12-04
 // Maintainer: blinklv <blinklv@icloud.com>
-// Last Change: 2019-12-09
+// Last Change: 2026-10-18
 
 package main
 
 import (
 	"bytes"
+	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
+	"math"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -75,6 +78,48 @@ func TestQueue(t *testing.T) {
 	}
 }
 
+func TestHardLinks(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "links")
+	defer os.RemoveAll(dir)
+
+	content := []byte("Hello, World!")
+	ioutil.WriteFile(filepath.Join(dir, "a"), content, 0644)
+	ioutil.WriteFile(filepath.Join(dir, "c"), content, 0644)
+	for _, name := range []string{"b", "d/e"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		if err := os.Link(filepath.Join(dir, "a"), filepath.Join(dir, name)); err != nil {
+			t.Skipf("hard links are unsupported: %s", err)
+		}
+	}
+
+	creator, *_depth = md5.New, 2
+	defer func() { *_depth = 1 }()
+
+	var (
+		a      = assert.New(t)
+		before = meter.bytes
+		nodes  = make(map[string]*node)
+	)
+
+	for n := range queue(digester(make(trigger), walk(make(trigger), []string{dir}))) {
+		nodes[n.path] = n
+	}
+
+	sum := md5.Sum(content)
+	for _, name := range []string{"a", "b", "c", "d/e"} {
+		n := nodes[filepath.Join(dir, name)]
+		a.NoErrorf(n.err, "%s", name)
+		a.Equalf(sum[:], n.sum, "%s", name)
+	}
+
+	if _, ok := inode(nodes[filepath.Join(dir, "a")].FileInfo); ok {
+		a.Equal(nodes[filepath.Join(dir, "a")], nodes[filepath.Join(dir, "b")].link)
+		a.Equal(nodes[filepath.Join(dir, "a")], nodes[filepath.Join(dir, "d/e")].link)
+		a.Nil(nodes[filepath.Join(dir, "c")].link)
+		a.Equal(int64(2*len(content)), meter.bytes-before)
+	}
+}
+
 func TestDisplay(t *testing.T) {
 	for _, env := range []struct {
 		input       []*node
@@ -328,6 +373,37 @@ func TestSecretKeyDecode(t *testing.T) {
 	}
 }
 
+func TestParseSize(t *testing.T) {
+	for _, env := range []struct {
+		str  string
+		ok   bool
+		size int64
+	}{
+		{"", false, 0},
+		{"M", false, 0},
+		{"foo", false, 0},
+		{"1.5M", false, 0},
+		{"0", true, 0},
+		{"1024", true, 1024},
+		{"512K", true, 512 << 10},
+		{"512k", true, 512 << 10},
+		{"8M", true, 8 << 20},
+		{"1G", true, 1 << 30},
+		{"2T", true, 2 << 40},
+		{"7E", true, 7 << 60},
+		{"8E", false, 0},
+		{"9E", false, 0},
+		{"-8E", true, math.MinInt64},
+		{"-9E", false, 0},
+		{"9223372036854775807", true, math.MaxInt64},
+	} {
+		size, err := parseSize(env.str)
+		a := assert.New(t)
+		a.Equalf(env.ok, err == nil, "%+v", env)
+		a.Equalf(env.size, size, "%+v", env)
+	}
+}
+
 func TestRsort(t *testing.T) {
 	for _, env := range []struct {
 		strs   []string
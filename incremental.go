@@ -0,0 +1,119 @@
+// incremental.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding"
+	"hash"
+	"io"
+)
+
+// Size of the last block of the hashed contents. Its hash is saved with the
+// hash state to verify that the file has only grown since the last run.
+const verifyBlockSize = 4 << 10
+
+// Directory of incremental states. It's empty when the incremental option
+// is not specified.
+var increments stateStore
+
+// increment tracks the hash state of an append-only file at EOF, so the next
+// run only needs to read the new bytes appended to the file.
+type increment struct {
+	file  string    // State file
+	state hashState // The restored state
+	h     encoding.BinaryMarshaler
+	r     io.ReaderAt
+}
+
+// increment() returns an increment instance of a node if its hash state can
+// be saved, otherwise returns nil. The previous state will be restored to the
+// h hash.Hash and the r io.Reader will be moved to the end of the contents
+// hashed last time if the file has only grown since then. Decompressed data is
+// never handled like the checkpoint.
+func (s stateStore) increment(n *node, h hash.Hash, r io.Reader) *increment {
+	m, ok := h.(encoding.BinaryMarshaler)
+	ra, _ := r.(io.ReaderAt)
+	if s == "" || !ok || ra == nil || !n.isfile() || n.codec != "" {
+		return nil
+	}
+
+	inc := &increment{file: s.file(n, "incremental"), h: m, r: ra}
+	hs := s.load(inc.file)
+	switch {
+	case hs == nil || hs.Path != n.path || hs.Size > n.Size():
+		return inc
+	case hs.Size == n.Size() && hs.ModTime != n.ModTime().UnixNano():
+		return inc // Modified in place.
+	case !bytes.Equal(hs.Tail, tail(ra, hs.Offset)):
+		return inc // Earlier contents have changed, rehash the whole file.
+	}
+
+	u, ok := h.(encoding.BinaryUnmarshaler)
+	if !ok || u.UnmarshalBinary(hs.State) != nil {
+		h.Reset()
+		return inc
+	}
+
+	if s, ok := r.(io.Seeker); !ok {
+		h.Reset()
+	} else if _, err := s.Seek(hs.Offset, io.SeekStart); err != nil {
+		h.Reset()
+	} else {
+		inc.state = *hs
+	}
+	return inc
+}
+
+// offset() returns the number of bytes have been hashed in the restored state.
+func (inc *increment) offset() int64 {
+	if inc == nil {
+		return 0
+	}
+	return inc.state.Offset
+}
+
+// update() saves the hash state at EOF. The offset parameter is the number
+// of bytes have been hashed, which is also the size of the file.
+func (inc *increment) update(n *node, offset int64) {
+	if inc == nil {
+		return
+	}
+
+	state, err := inc.h.MarshalBinary()
+	if err != nil {
+		return
+	}
+
+	increments.save(inc.file, &hashState{
+		Path:    n.path,
+		Size:    offset,
+		ModTime: n.ModTime().UnixNano(),
+		Offset:  offset,
+		State:   state,
+		Tail:    tail(inc.r, offset),
+	})
+}
+
+// tail() returns the SHA-256 digest of the last block before the offset.
+// If something wrong, returns nil.
+func tail(r io.ReaderAt, offset int64) []byte {
+	size := int64(verifyBlockSize)
+	if offset < size {
+		size = offset
+	}
+
+	block := make([]byte, size)
+	if n, _ := r.ReadAt(block, offset-size); n != len(block) {
+		return nil
+	}
+
+	sum := sha256.Sum256(block)
+	return sum[:]
+}
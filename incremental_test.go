@@ -0,0 +1,69 @@
+// incremental_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"crypto/sha1"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIncrement(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "incremental")
+	defer os.RemoveAll(dir)
+	increments = stateStore(filepath.Join(dir, "states"))
+	defer func() { increments = "" }()
+	os.Mkdir(string(increments), 0755)
+
+	path := filepath.Join(dir, "app.log")
+	for _, env := range []struct {
+		content string
+		append  bool
+		offset  int64 // Offset of the restored state.
+	}{
+		{strings.Repeat("foo\n", 4096), false, 0},
+		{strings.Repeat("bar\n", 10), true, 4 * 4096},
+		{"", true, 4*4096 + 40},
+		{"hello\n", true, 4*4096 + 40},
+		{strings.Repeat("foo\n", 1024), false, 0},
+		{strings.Repeat("bar\n", 1024), false, 0},
+	} {
+		flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if env.append {
+			flag = os.O_WRONLY | os.O_APPEND
+		}
+		f, _ := os.OpenFile(path, flag, 0644)
+		f.WriteString(env.content)
+		f.Close()
+
+		var (
+			a       = assert.New(t)
+			h       = sha1.New()
+			n       = (&node{}).init(path)
+			data, _ = ioutil.ReadFile(path)
+			r, _    = n.open()
+		)
+
+		inc := increments.increment(n, h, r)
+		a.Equalf(env.offset, inc.offset(), "%+v", env)
+		r.Close()
+
+		sum, err := n.digest(make(trigger), sha1.New())
+		a.NoErrorf(err, "%+v", env)
+		a.Equalf(sha1Sum(data), sum, "%+v", env)
+	}
+}
+
+func sha1Sum(data []byte) []byte {
+	sum := sha1.Sum(data)
+	return sum[:]
+}
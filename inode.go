@@ -0,0 +1,25 @@
+// inode.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// Returns the identity of a file which has multiple hard links. If the file
+// has only one link or its identity is unknown, the ok result will be false.
+// (For Unix-Like System)
+func inode(fi os.FileInfo) (id fileID, ok bool) {
+	if st, _ := fi.Sys().(*syscall.Stat_t); st != nil && st.Nlink > 1 {
+		return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+	}
+	return id, false
+}
@@ -0,0 +1,18 @@
+// inode_windows.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build windows
+
+package main
+
+import "os"
+
+// Returns the identity of a file which has multiple hard links. The file index
+// isn't available in os.FileInfo, so the ok result is always false. (For Windows)
+func inode(fi os.FileInfo) (id fileID, ok bool) {
+	return id, false
+}
@@ -0,0 +1,175 @@
+// journal.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The first line of a journal file. It's followed by the quoted command
+// arguments which created the journal.
+const journalHeader = "go-hash journal"
+
+// Checkpoint journal of a run. It records the results which have been
+// outputted, so a run interrupted by OS signals can resume from where
+// it stopped when re-running with the same arguments.
+type journal struct {
+	file    *os.File
+	records map[string]*record // Records by paths
+}
+
+// A completed result recorded in the journal.
+type record struct {
+	i     int    // Walk sequence, members of an archive share the one of the archive
+	path  string // Filepath
+	sum   []byte // Digest
+	codec string // Decompression format of the data, empty means raw data
+}
+
+// open() opens the journal file and loads records in it. If the file doesn't
+// exist, it will be created. A partial record at the end of the file, which is
+// left by a killed process, is discarded. The args parameter is used to check whether the
+// journal was created by the same command arguments.
+func (j *journal) open(path string, args []string) (*journal, error) {
+	var (
+		err   error
+		quote = strconv.Quote(strings.Join(args, "\x00"))
+	)
+
+	if j.file, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(j.file)
+	if err != nil {
+		return j.check(err)
+	}
+
+	// The last record may be partially written when the process was killed,
+	// it's truncated so the file can be appended again.
+	if i := bytes.LastIndexByte(data, '\n'); i+1 < len(data) {
+		data = data[:i+1]
+		if err = j.file.Truncate(int64(len(data))); err != nil {
+			return j.check(err)
+		}
+	}
+
+	j.records = make(map[string]*record)
+	lines := strings.Split(string(data), "\n")
+	lines = lines[:len(lines)-1] // The file always ends with a newline.
+
+	if len(lines) == 0 {
+		_, err = fprintf(j.file, "%s %s\n", journalHeader, quote)
+		return j.check(err)
+	}
+
+	if lines[0] != journalHeader+" "+quote {
+		return j.check(errorf("journal '%s' was created with different arguments", path))
+	}
+
+	for _, line := range lines[1:] {
+		r, err := parseRecord(line)
+		if err != nil {
+			return j.check(errorf("journal '%s' is corrupted: %s", path, err))
+		}
+		j.records[r.path] = r
+	}
+	return j, nil
+}
+
+// lookup() returns the record of the node. If the node hasn't been recorded
+// or its walk sequence doesn't match, returns nil.
+func (j *journal) lookup(n *node) *record {
+	if j == nil {
+		return nil
+	}
+
+	if r := j.records[n.path]; r != nil && r.i == n.i {
+		return r
+	}
+	return nil
+}
+
+// record() appends results from the input channel to the journal file and
+// pushes them to the output channel. Only digests of regular files will be
+// recorded; errors and the standard input will be retried by the next run.
+func (j *journal) record(input chan *node) (output chan *node) {
+	if j == nil {
+		return input
+	}
+
+	output = make(chan *node)
+	go func() {
+		for n := range input {
+			if n.err == nil && n.path != "" && n.isregular() && j.lookup(n) == nil {
+				fprintf(j.file, "%d %x %s %s\n", n.i, n.sum, strconv.Quote(n.codec), strconv.Quote(n.path))
+			}
+			output <- n
+		}
+		close(output)
+	}()
+	return output
+}
+
+// close() closes the journal file. If the remove parameter is true, the
+// journal file will be removed too, which means the run has done.
+func (j *journal) close(remove bool) {
+	if j == nil {
+		return
+	}
+
+	j.file.Close()
+	if remove {
+		os.Remove(j.file.Name())
+	}
+}
+
+// check() closes the journal file when the err parameter is not nil.
+func (j *journal) check(err error) (*journal, error) {
+	if err != nil {
+		j.file.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// parseRecord() parses a line of the journal file. Its format is:
+// 'walk sequence' 'hex encoded digest' 'quoted codec' 'quoted path'.
+func parseRecord(line string) (*record, error) {
+	strs := strings.SplitN(line, " ", 4)
+	if len(strs) != 4 {
+		return nil, errorf("invalid record '%s'", line)
+	}
+
+	var (
+		r   = &record{}
+		err error
+	)
+
+	if r.i, err = strconv.Atoi(strs[0]); err != nil {
+		return nil, err
+	}
+
+	if r.sum, err = hex.DecodeString(strs[1]); err != nil {
+		return nil, err
+	}
+
+	if r.codec, err = strconv.Unquote(strs[2]); err != nil {
+		return nil, err
+	}
+
+	if r.path, err = strconv.Unquote(strs[3]); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,108 @@
+// journal_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "journal")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "go-hash.journal")
+
+	a := assert.New(t)
+	j, err := (&journal{}).open(path, []string{"-algo", "sha1", "foo"})
+	a.NoError(err)
+
+	input := []*node{
+		&node{i: 0, path: "foo/a", sum: []byte{0x12, 0x34}},
+		&node{i: 1, path: "foo/b", err: errorf("something wrong!")},
+		&node{i: 2, path: "foo/c c", sum: []byte{0xab, 0xcd}, codec: "gzip"},
+		&node{i: 3, sum: []byte{0xef}},
+		&node{i: 5, path: "foo/e.zip!a", sum: []byte{0x01}, container: "foo/e.zip"},
+		&node{i: 5, path: "foo/e.zip!b", sum: []byte{0x02}, container: "foo/e.zip"},
+	}
+	for range j.record(toInput(input)) {
+	}
+	j.close(false)
+
+	_, err = (&journal{}).open(path, []string{"-algo", "md5", "foo"})
+	a.Error(err)
+
+	j, err = (&journal{}).open(path, []string{"-algo", "sha1", "foo"})
+	a.NoError(err)
+	a.Equal([]byte{0x12, 0x34}, j.lookup(&node{i: 0, path: "foo/a"}).sum)
+	a.Nil(j.lookup(&node{i: 0, path: "foo/b"}))
+	a.Nil(j.lookup(&node{i: 1, path: "foo/b"}))
+	a.Equal(&record{2, "foo/c c", []byte{0xab, 0xcd}, "gzip"}, j.lookup(&node{i: 2, path: "foo/c c"}))
+	a.Nil(j.lookup(&node{i: 3}))
+
+	// Members of an archive share the walk sequence of the archive.
+	a.Equal([]byte{0x01}, j.lookup(&node{i: 5, path: "foo/e.zip!a"}).sum)
+	a.Equal([]byte{0x02}, j.lookup(&node{i: 5, path: "foo/e.zip!b"}).sum)
+	a.Nil(j.lookup(&node{i: 6, path: "foo/e.zip!b"}))
+	j.close(false)
+
+	// A partial record left by a killed process is discarded, and the journal
+	// can be appended again.
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString("4 abcd \"\" \"foo/d")
+	f.Close()
+
+	j, err = (&journal{}).open(path, []string{"-algo", "sha1", "foo"})
+	a.NoError(err)
+	a.Nil(j.lookup(&node{i: 4, path: "foo/d"}))
+	for range j.record(toInput([]*node{&node{i: 4, path: "foo/d", sum: []byte{0x56}}})) {
+	}
+	j.close(false)
+
+	j, err = (&journal{}).open(path, []string{"-algo", "sha1", "foo"})
+	a.NoError(err)
+	a.Equal([]byte{0x12, 0x34}, j.lookup(&node{i: 0, path: "foo/a"}).sum)
+	a.Equal([]byte{0x56}, j.lookup(&node{i: 4, path: "foo/d"}).sum)
+
+	// A partial header is discarded too.
+	ioutil.WriteFile(path, []byte(journalHeader), 0644)
+	j, err = (&journal{}).open(path, []string{"-algo", "sha1", "foo"})
+	a.NoError(err)
+	j.close(false)
+	j, err = (&journal{}).open(path, []string{"-algo", "sha1", "foo"})
+	a.NoError(err)
+
+	j.close(true)
+	_, err = os.Stat(path)
+	a.True(os.IsNotExist(err))
+}
+
+func TestParseRecord(t *testing.T) {
+	for _, env := range []struct {
+		line string
+		ok   bool
+		r    *record
+	}{
+		{"", false, nil},
+		{"1 abcd", false, nil},
+		{"x abcd \"\" \"foo\"", false, nil},
+		{"1 xyz \"\" \"foo\"", false, nil},
+		{"1 abcd \"foo\"", false, nil},
+		{"1 abcd \"\" foo", false, nil},
+		{"1 abcd gzip \"foo\"", false, nil},
+		{"1 abcd \"\" \"foo bar\"", true, &record{1, "foo bar", []byte{0xab, 0xcd}, ""}},
+		{"1 abcd \"gzip\" \"foo bar\"", true, &record{1, "foo bar", []byte{0xab, 0xcd}, "gzip"}},
+	} {
+		r, err := parseRecord(env.line)
+		a := assert.New(t)
+		a.Equalf(env.ok, err == nil, "%+v", env)
+		a.Equalf(env.r, r, "%+v", env)
+	}
+}
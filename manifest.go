@@ -0,0 +1,146 @@
+// manifest.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The name of manifest files, it's determined by the hash algorithm, like
+// 'SHA256SUMS' or 'MD5SUMS'.
+var manifestName string
+
+// manifest() loads the manifest file of a directory node in the verify mode and
+// returns the children which should be walked. Expected digests of files listed
+// in the manifest will be stored to the want field, other files are ignored but
+// subdirectories are always walked. Files listed in the manifest but missing in
+// the directory will be reported as errors. If the manifest is invalid, the error
+// will be stored to the err field of the current node.
+func (n *node) manifest(children []*node) []*node {
+	f, err := os.Open(filepath.Join(n.path, manifestName))
+	if err != nil && !os.IsNotExist(err) {
+		n.err = err
+	}
+
+	var (
+		sums = make(map[string][]byte)
+		ns   = make([]*node, 0, len(children))
+	)
+
+	if err == nil {
+		defer f.Close()
+		for scanner := bufio.NewScanner(f); scanner.Scan(); {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+
+			sum, name, err := parseSum(scanner.Text())
+			if err == nil && name == "" {
+				err = errorf("missing filename in line '%s'", scanner.Text())
+			}
+
+			if err != nil {
+				n.err = errorf("invalid manifest: %s", err)
+				break
+			}
+			sums[filepath.Clean(filepath.FromSlash(name))] = sum
+		}
+	}
+
+	for _, child := range children {
+		name := child.filename()
+		switch sum := sums[name]; {
+		case child.isdir():
+			ns = append(ns, child)
+		case sum != nil:
+			child.want = sum
+			ns = append(ns, child)
+		case name != manifestName:
+			report.ignore()
+		}
+		delete(sums, name)
+	}
+
+	// The remaining files are either missing or in subdirectories.
+	for name, sum := range sums {
+		child := (&node{depth: n.depth + 1}).init(filepath.Join(n.path, name))
+		child.want = sum
+		ns = append(ns, child)
+	}
+
+	sort.Slice(ns, func(i, j int) bool { return ns[i].path > ns[j].path })
+	return ns
+}
+
+// manifests() writes a manifest file into each directory in the write mode,
+// which lists digests of files directly in the directory with their names in
+// the walk order.
+// Nodes of directories are held until all of their descendants have been
+// outputted, because their manifests can only be written at that time. If
+// writing fails, the error will be stored to the err field of the directory.
+func manifests(input chan *node) (output chan *node) {
+	if *_manifest != "write" {
+		return input
+	}
+
+	output = make(chan *node)
+	go func() {
+		type dir struct {
+			*node
+			lines []string
+		}
+
+		// S is a stack of directories whose descendants are being outputted.
+		// Nodes are walked in pre-order, so a node belongs to the directory
+		// on the top of the stack whose depth is less than its depth.
+		var S []*dir
+		flush := func(depth int) {
+			for len(S) > 0 && S[len(S)-1].depth >= depth {
+				d := S[len(S)-1]
+				S = S[:len(S)-1]
+				// A stale manifest is removed if there's no file now.
+				var (
+					name = filepath.Join(d.path, manifestName)
+					err  error
+				)
+
+				if len(d.lines) > 0 {
+					err = ioutil.WriteFile(name, []byte(strings.Join(d.lines, "")), 0644)
+				} else if err = os.Remove(name); os.IsNotExist(err) {
+					err = nil
+				}
+
+				if err != nil {
+					d.err = errorf("write manifest failed: %s", err)
+				}
+				output <- d.node
+			}
+		}
+
+		for n := range input {
+			flush(n.depth)
+			if n.err == nil && n.isdir() && n.depth < *_depth {
+				S = append(S, &dir{node: n})
+				continue
+			}
+
+			if d := len(S) - 1; d >= 0 && S[d].depth == n.depth-1 && n.err == nil && n.isfile() && n.isregular() {
+				S[d].lines = append(S[d].lines, formatSum(n.sum, filepath.Base(n.path)))
+			}
+			output <- n
+		}
+		flush(0)
+		close(output)
+	}()
+	return output
+}
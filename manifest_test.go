@@ -0,0 +1,87 @@
+// manifest_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"crypto/md5"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "manifest")
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"a.txt":     "Hello",
+		"b/c.txt":   "World",
+		"b/d.txt":   "Foo",
+		"b/e/f.txt": "Bar",
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+	os.MkdirAll(filepath.Join(dir, "g"), 0755)
+
+	creator, sumSize, manifestName, *_depth = md5.New, md5.Size, "MD5SUMS", 5
+	defer func() { *_manifest, *_depth = "", 1 }()
+
+	run := func(mode string, root string) map[string]*node {
+		*_manifest = mode
+		nodes := make(map[string]*node)
+		for n := range manifests(queue(digester(make(trigger), walk(make(trigger), []string{root})))) {
+			rel, _ := filepath.Rel(root, n.path)
+			nodes[filepath.ToSlash(rel)] = n
+		}
+		return nodes
+	}
+
+	a := assert.New(t)
+	nodes := run("write", dir)
+	for name, content := range map[string]string{
+		"MD5SUMS":     sprintf("%x  a.txt\n", md5.Sum([]byte("Hello"))),
+		"b/MD5SUMS":   sprintf("%x  c.txt\n%x  d.txt\n", md5.Sum([]byte("World")), md5.Sum([]byte("Foo"))),
+		"b/e/MD5SUMS": sprintf("%x  f.txt\n", md5.Sum([]byte("Bar"))),
+	} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		a.NoErrorf(err, "%s", name)
+		a.Equalf(content, string(data), "%s", name)
+	}
+	_, err := os.Stat(filepath.Join(dir, "g", "MD5SUMS"))
+	a.True(os.IsNotExist(err))
+
+	// Manifest files written by the previous run are ignored.
+	a.Len(run("write", dir), len(nodes))
+
+	ioutil.WriteFile(filepath.Join(dir, "b/d.txt"), []byte("Foo!"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "b/h.txt"), []byte("New"), 0644)
+	os.Remove(filepath.Join(dir, "b/e/f.txt"))
+
+	nodes = run("verify", dir)
+	a.Equal(filepath.Join(dir, "a.txt")+": OK", nodes["a.txt"].String())
+	a.Equal(filepath.Join(dir, "b/c.txt")+": OK", nodes["b/c.txt"].String())
+	a.Equal(filepath.Join(dir, "b/d.txt")+": FAILED", nodes["b/d.txt"].String())
+	a.True(os.IsNotExist(nodes["b/e/f.txt"].err))
+	a.Nil(nodes["b/h.txt"]) // Not listed in the manifest.
+	a.Nil(nodes["MD5SUMS"])
+
+	// A subtree can be verified independently.
+	nodes = run("verify", filepath.Join(dir, "b"))
+	a.Equal(filepath.Join(dir, "b/c.txt")+": OK", nodes["c.txt"].String())
+	a.Equal(filepath.Join(dir, "b/d.txt")+": FAILED", nodes["d.txt"].String())
+
+	// An invalid manifest is reported on the directory.
+	ioutil.WriteFile(filepath.Join(dir, "b/MD5SUMS"), []byte("invalid\n"), 0644)
+	nodes = run("verify", dir)
+	a.Error(nodes["b"].err)
+	a.Nil(nodes["b/c.txt"])
+}
@@ -0,0 +1,213 @@
+// nar.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"hash"
+	"os"
+	"strings"
+)
+
+// The magic string at the beginning of Nix Archives (NARs).
+const narMagic = "nix-archive-1"
+
+// The alphabet of the Nix base32 encoding, which omits 'e', 'o', 'u' and 't'.
+const nix32Alphabet = "0123456789abcdfghijklmnpqrsvwxyz"
+
+// Hash algorithms supported by nix-hash.
+var narAlgos = map[string]bool{"md5": true, "sha1": true, "sha256": true, "sha512": true}
+
+// nars() serializes each root into a NAR and outputs the root node whose digest
+// is the hash of the NAR. Nodes are walked in pre-order and children of a
+// directory are sorted by names, which is exactly the order of entries in NARs,
+// so files are read sequentially and the NAR is never held in memory.
+func nars(exit trigger, input chan *node) (output chan *node) {
+	if !(*_nar) {
+		return input
+	}
+
+	output = make(chan *node)
+	go func() {
+		var a *nar
+		for n := range input {
+			if n.depth == 0 {
+				if a != nil {
+					output <- a.finish()
+				}
+				a = (&nar{h: creator()}).init(n)
+			}
+			a.add(exit, n)
+		}
+		if a != nil {
+			output <- a.finish()
+		}
+		close(output)
+	}()
+	return output
+}
+
+// nar serializes files under a root into a NAR and writes it to the hash.
+type nar struct {
+	root *node
+	h    hash.Hash
+	dirs int // The number of open directories, whose depths are 0 to dirs-1.
+	err  error
+}
+
+// init() initializes the nar by the root node and writes the magic string.
+func (a *nar) init(root *node) *nar {
+	a.root, a.dirs, a.err = root, 0, nil
+	a.h.Reset()
+	a.str(narMagic)
+	return a
+}
+
+// add() serializes the node, directories deeper than it are closed first.
+// Regular files, directories and symlinks are supported.
+func (a *nar) add(exit trigger, n *node) {
+	if a.err != nil {
+		return
+	} else if n.err != nil {
+		a.err = n.err
+		return
+	} else if n.FileInfo == nil {
+		a.err = errorf("%s can't be serialized into a NAR", n._path())
+		return
+	}
+
+	a.close(n.depth)
+	if n.depth > 0 {
+		a.str("entry", "(", "name", n.Name(), "node")
+	}
+	a.str("(", "type")
+
+	switch mode := n.Mode(); {
+	case mode.IsDir():
+		a.str("directory")
+		a.dirs++
+		return
+	case mode.IsRegular():
+		a.str("regular")
+		if mode&0100 != 0 {
+			a.str("executable", "")
+		}
+		a.str("contents")
+		a.contents(exit, n)
+	case mode&os.ModeSymlink != 0:
+		var target string
+		if target, a.err = os.Readlink(n.path); a.err == nil {
+			a.str("symlink", "target", target)
+		}
+	default:
+		a.err = errorf("unsupported file type of %s", n.path)
+	}
+	a.end(n.depth)
+}
+
+// contents() writes the size, the data and the padding of the regular file.
+func (a *nar) contents(exit trigger, n *node) {
+	size := n.Size()
+	a.h.Write(appendWord(nil, uint64(size)))
+	if _, a.err = n.feed(exit, size, func(p []byte) { a.h.Write(p) }); a.err == nil {
+		a.pad(size)
+	}
+	meter.done()
+}
+
+// close() closes open directories whose depths are not less than the depth.
+func (a *nar) close(depth int) {
+	for a.dirs > depth {
+		a.dirs--
+		a.end(a.dirs)
+	}
+}
+
+// end() writes the end of the node at the depth, nodes below the root are
+// enclosed in entries.
+func (a *nar) end(depth int) {
+	a.str(")")
+	if depth > 0 {
+		a.str(")")
+	}
+}
+
+// finish() closes all open directories and returns the node whose digest is
+// the hash of the NAR.
+func (a *nar) finish() *node {
+	n := &node{FileInfo: a.root.FileInfo, path: a.root.path, i: a.root.i, err: a.err}
+	if n.err == nil {
+		a.close(0)
+		n.sum = a.h.Sum(nil)
+		n.err = n.verify()
+	}
+	return n
+}
+
+// str() writes strings to the hash, each one is prefixed with its little-endian
+// 64-bit length and padded with zeros to a multiple of 8 bytes.
+func (a *nar) str(strs ...string) {
+	for _, s := range strs {
+		a.h.Write(appendWord(nil, uint64(len(s))))
+		a.h.Write([]byte(s))
+		a.pad(int64(len(s)))
+	}
+}
+
+// pad() writes zeros to pad the data of the size to a multiple of 8 bytes.
+func (a *nar) pad(size int64) {
+	if rest := size % 8; rest > 0 {
+		a.h.Write(make([]byte, 8-rest))
+	}
+}
+
+// encodeNix32() encodes the digest in the Nix base32 encoding, whose bits are
+// taken from the end of the digest.
+func encodeNix32(sum []byte) string {
+	var (
+		size = (len(sum)*8-1)/5 + 1
+		buf  = make([]byte, 0, size)
+	)
+
+	for n := size - 1; n >= 0; n-- {
+		b := uint(n * 5)
+		i, j := b/8, b%8
+		c := sum[i] >> j
+		if int(i) < len(sum)-1 {
+			c |= sum[i+1] << (8 - j)
+		}
+		buf = append(buf, nix32Alphabet[c&0x1f])
+	}
+	return string(buf)
+}
+
+// decodeNix32() decodes a Nix base32 string, it's the reverse operation of the
+// encodeNix32() function.
+func decodeNix32(str string) ([]byte, error) {
+	size := len(str) * 5 / 8
+	if size == 0 || (size*8-1)/5+1 != len(str) {
+		return nil, errorf("invalid length of nix32 string")
+	}
+
+	sum := make([]byte, size)
+	for n := 0; n < len(str); n++ {
+		digit := strings.IndexByte(nix32Alphabet, str[len(str)-n-1])
+		if digit < 0 {
+			return nil, errorf("invalid nix32 character '%c'", str[len(str)-n-1])
+		}
+
+		b := uint(n * 5)
+		i, j := b/8, b%8
+		sum[i] |= byte(digit << j)
+		if int(i) < size-1 {
+			sum[i+1] |= byte(digit >> (8 - j))
+		} else if digit>>(8-j) != 0 {
+			return nil, errorf("invalid nix32 string")
+		}
+	}
+	return sum, nil
+}
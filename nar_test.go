@@ -0,0 +1,138 @@
+// nar_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNix32(t *testing.T) {
+	for _, env := range []struct {
+		data   string
+		result string
+	}{
+		{"", "0mdqa9w1p6cmli6976v4wi0sw9r4p5prkj7lzfd1877wk11c9c73"},
+		{"hello", "094qif9n4cq4fdg459qzbhg1c6wywawwaaivx0k0x8xhbyx4vwic"},
+	} {
+		a := assert.New(t)
+		sum := sha256.Sum256([]byte(env.data))
+		a.Equalf(env.result, encodeNix32(sum[:]), "%+v", env)
+
+		data, err := decodeNix32(env.result)
+		a.NoErrorf(err, "%+v", env)
+		a.Equalf(sum[:], data, "%+v", env)
+	}
+
+	for _, size := range []int{1, 16, 20, 32, 64} {
+		sum := bytes.Repeat([]byte{0xa5}, size)
+		data, err := decodeNix32(encodeNix32(sum))
+		assert.NoErrorf(t, err, "%d", size)
+		assert.Equalf(t, sum, data, "%d", size)
+	}
+
+	for _, str := range []string{"", "0", "0mdqa9w1p6cmli6976v4wi0sw9r4p5prkj7lzfd1877wk11c9c7e", "z" + strings.Repeat("0", 25)} {
+		_, err := decodeNix32(str)
+		assert.Errorf(t, err, "%s", str)
+	}
+}
+
+func TestNars(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "nar")
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "pkg", "bin"), 0755)
+	os.MkdirAll(filepath.Join(dir, "pkg", "share", ".hidden"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "hello"), []byte("hello"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "pkg", "bin", "run"), []byte("#!/bin/sh\n"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "pkg", "share", "readme"), []byte("hello\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "pkg", "share", ".hidden", "empty"), nil, 0644)
+	link := os.Symlink("../share/readme", filepath.Join(dir, "pkg", "bin", "link")) == nil
+
+	// Serializes the package by the definition of NARs.
+	str := func(strs ...string) string {
+		var buf bytes.Buffer
+		for _, s := range strs {
+			buf.Write(appendWord(nil, uint64(len(s))))
+			buf.WriteString(s)
+			buf.Write(make([]byte, (8-len(s)%8)%8))
+		}
+		return buf.String()
+	}
+	entry := func(name, node string) string {
+		return str("entry", "(", "name", name, "node") + node + str(")")
+	}
+	regular := func(data string, executable bool) string {
+		if executable && runtime.GOOS != "windows" {
+			return str("(", "type", "regular", "executable", "", "contents", data, ")")
+		}
+		return str("(", "type", "regular", "contents", data, ")")
+	}
+
+	bin := str("(", "type", "directory")
+	if link {
+		bin += entry("link", str("(", "type", "symlink", "target", "../share/readme", ")"))
+	}
+	bin += entry("run", regular("#!/bin/sh\n", true)) + str(")")
+	share := str("(", "type", "directory") +
+		entry(".hidden", str("(", "type", "directory")+entry("empty", regular("", false))+str(")")) +
+		entry("readme", regular("hello\n", false)) + str(")")
+	pkg := str(narMagic, "(", "type", "directory") + entry("bin", bin) + entry("share", share) + str(")")
+
+	creator, sumSize, *_nar, *_all, *_depth, *_encoding = sha256.New, sha256.Size, true, true, math.MaxInt32, "nix32"
+	defer func() { *_nar, *_all, *_depth, *_encoding = false, false, 1, "hex" }()
+
+	for _, env := range []struct {
+		root   string
+		result []byte
+	}{
+		{filepath.Join(dir, "hello"), []byte(str(narMagic) + regular("hello", false))},
+		{filepath.Join(dir, "pkg"), []byte(pkg)},
+	} {
+		a := assert.New(t)
+		sum := sha256.Sum256(env.result)
+
+		var ns []*node
+		for n := range nars(make(trigger), queue(digester(make(trigger), walk(make(trigger), []string{env.root})))) {
+			ns = append(ns, n)
+		}
+
+		if a.Lenf(ns, 1, "%+v", env) {
+			a.NoErrorf(ns[0].err, "%+v", env)
+			a.Equalf(env.root, ns[0].path, "%+v", env)
+			a.Equalf(sum[:], ns[0].sum, "%+v", env)
+		}
+	}
+
+	// The NAR hash of a file containing 'hello' in nix32.
+	for n := range nars(make(trigger), queue(digester(make(trigger), walk(make(trigger), []string{filepath.Join(dir, "hello")})))) {
+		assert.Equal(t, "0sg9f58l1jj88w6pdrfdpj5x9b1zrwszk84j81zvby36q9whhhqa", n.encode())
+	}
+
+	// Other hash algorithms are supported by nix-hash too.
+	creator = md5.New
+	defer func() { creator = sha256.New }()
+	for n := range nars(make(trigger), queue(digester(make(trigger), walk(make(trigger), []string{filepath.Join(dir, "pkg")})))) {
+		sum := md5.Sum([]byte(pkg))
+		assert.Equal(t, sum[:], n.sum)
+	}
+
+	// Errors of files are reported on roots.
+	for n := range nars(make(trigger), queue(digester(make(trigger), walk(make(trigger), []string{filepath.Join(dir, "none")})))) {
+		assert.Error(t, n.err)
+	}
+}
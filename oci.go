@@ -0,0 +1,151 @@
+// oci.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Media types of OCI (and Docker) manifests and indexes, which reference other
+// blobs by descriptors.
+var manifestTypes = map[string]bool{
+	"application/vnd.oci.image.index.v1+json":                   true,
+	"application/vnd.oci.image.manifest.v1+json":                true,
+	"application/vnd.docker.distribution.manifest.list.v2+json": true,
+	"application/vnd.docker.distribution.manifest.v2+json":      true,
+}
+
+// The grammar of algorithms in OCI digests.
+var ociAlgorithm = regexp.MustCompile(`^[a-z0-9]+([+._-][a-z0-9]+)*$`)
+
+// A descriptor references a blob by its digest and size.
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// ociManifest contains descriptors in an OCI index or manifest.
+type ociManifest struct {
+	MediaType string       `json:"mediaType"`
+	Manifests []descriptor `json:"manifests"` // Index
+	Config    *descriptor  `json:"config"`    // Manifest
+	Layers    []descriptor `json:"layers"`    // Manifest
+}
+
+// descriptors() returns all descriptors in the OCI index or manifest.
+func (m *ociManifest) descriptors() []descriptor {
+	var ds []descriptor
+	ds = append(append(ds, m.Manifests...), m.Layers...)
+	if m.Config != nil {
+		ds = append(ds, *m.Config)
+	}
+	return ds
+}
+
+// layout() returns nodes of all blobs referenced by the OCI image layout in the
+// directory. The expected digest of a blob is stored to the want field, and the
+// error will be stored to the err field of the blob if its size is different
+// from the descriptor. Blobs of manifests and indexes are parsed recursively.
+// If the layout is invalid, the error will be stored to the err field of the
+// current node.
+func (n *node) layout() []*node {
+	var version struct {
+		ImageLayoutVersion string `json:"imageLayoutVersion"`
+	}
+
+	if n.err = readJSON(filepath.Join(n.path, "oci-layout"), &version); n.err != nil {
+		return nil
+	} else if version.ImageLayoutVersion == "" {
+		n.err = errorf("invalid OCI image layout")
+		return nil
+	}
+
+	index := &ociManifest{}
+	if n.err = readJSON(filepath.Join(n.path, "index.json"), index); n.err != nil {
+		return nil
+	}
+
+	var (
+		ns      []*node
+		visited = make(map[string]bool)
+		queue   = index.descriptors()
+		d       descriptor
+	)
+
+	for len(queue) > 0 {
+		d, queue = queue[0], queue[1:]
+		if visited[d.Digest] {
+			continue
+		}
+		visited[d.Digest] = true
+
+		m := n.blob(d)
+		if m.err == nil && manifestTypes[d.MediaType] {
+			manifest := &ociManifest{}
+			if m.err = readJSON(m.path, manifest); m.err == nil {
+				queue = append(queue, manifest.descriptors()...)
+			}
+		}
+		ns = append(ns, m)
+	}
+
+	sort.Slice(ns, func(i, j int) bool { return ns[i].path > ns[j].path })
+	return ns
+}
+
+// blob() creates a node of the blob referenced by the descriptor.
+func (n *node) blob(d descriptor) *node {
+	var (
+		m    = &node{depth: n.depth + 1}
+		strs = strings.SplitN(d.Digest, ":", 2)
+		sum  []byte
+		err  error
+	)
+
+	if len(strs) == 2 && ociAlgorithm.MatchString(strs[0]) {
+		sum, err = hex.DecodeString(strs[1])
+	}
+
+	if len(strs) != 2 || sum == nil || err != nil {
+		m.path, m.err = filepath.Join(n.path, "blobs"), errorf("invalid digest '%s'", d.Digest)
+		return m
+	}
+
+	switch m.init(filepath.Join(n.path, "blobs", strs[0], strs[1])); {
+	case m.err != nil:
+	case strs[0] != algoName():
+		m.err = errorf("unsupported digest algorithm '%s'", strs[0])
+	case len(sum) != sumSize:
+		m.err = errorf("invalid digest '%s'", d.Digest)
+	case m.Size() != d.Size:
+		m.err = errorf("size mismatch, expected %d", d.Size)
+	default:
+		m.want = sum
+	}
+	return m
+}
+
+// readJSON() reads the named file and decodes it as JSON.
+func readJSON(name string, v interface{}) error {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(data, v); err != nil {
+		return errorf("parse %s failed: %s", filepath.Base(name), err)
+	}
+	return nil
+}
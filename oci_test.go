@@ -0,0 +1,111 @@
+// oci_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLayout(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "oci")
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "blobs", "sha256"), 0755)
+
+	blob := func(mediaType string, data []byte) descriptor {
+		sum := sha256.Sum256(data)
+		ioutil.WriteFile(filepath.Join(dir, "blobs", "sha256", sprintf("%x", sum)), data, 0644)
+		return descriptor{mediaType, sprintf("sha256:%x", sum), int64(len(data))}
+	}
+
+	var (
+		config = blob("application/vnd.oci.image.config.v1+json", []byte("{}"))
+		layer1 = blob("application/vnd.oci.image.layer.v1.tar", []byte("Hello"))
+		layer2 = blob("application/vnd.oci.image.layer.v1.tar", []byte("World"))
+		layer3 = blob("application/vnd.oci.image.layer.v1.tar", []byte("Foo"))
+		layer4 = descriptor{"application/vnd.oci.image.layer.v1.tar", "sha256:" + sprintf("%064d", 0), 3}
+		layer5 = descriptor{"application/vnd.oci.image.layer.v1.tar", "sha256:../../oci-layout", 3}
+	)
+	layer3.Size = 4
+
+	data, _ := json.Marshal(ociManifest{Config: &config, Layers: []descriptor{layer1, layer2, layer3, layer4, layer5}})
+	manifest := blob("application/vnd.oci.image.manifest.v1+json", data)
+	data, _ = json.Marshal(ociManifest{Manifests: []descriptor{manifest, manifest}})
+	index := blob("application/vnd.oci.image.index.v1+json", data)
+	data, _ = json.Marshal(ociManifest{Manifests: []descriptor{index}})
+	ioutil.WriteFile(filepath.Join(dir, "index.json"), data, 0644)
+
+	// The content of the second layer is corrupted.
+	ioutil.WriteFile(filepath.Join(dir, "blobs", "sha256", layer2.Digest[7:]), []byte("Worle"), 0644)
+
+	a := assert.New(t)
+	creator, sumSize, *_algo = sha256.New, sha256.Size, "sha256"
+	defer func() { *_algo = "md5" }()
+
+	n := (&node{}).init(dir)
+	a.Nil(n.layout())
+	a.True(os.IsNotExist(n.err))
+
+	ioutil.WriteFile(filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0644)
+	n = (&node{}).init(dir)
+	ns := n.layout()
+	a.NoError(n.err)
+	a.Len(ns, 8)
+
+	nodes := make(map[string]*node)
+	for m := range digester(make(trigger), toInput(ns)) {
+		nodes[m.path] = m
+	}
+
+	for _, env := range []struct {
+		d  descriptor
+		ok bool
+	}{
+		{config, true},
+		{layer1, true},
+		{layer2, false},
+		{layer3, false},
+		{layer4, false},
+		{manifest, true},
+		{index, true},
+	} {
+		m := nodes[filepath.Join(dir, "blobs", "sha256", env.d.Digest[7:])]
+		if a.NotNilf(m, "%+v", env) {
+			a.Equalf(env.ok, m.err == nil, "%+v", env)
+		}
+	}
+	a.Error(nodes[filepath.Join(dir, "blobs")].err) // Invalid digest.
+}
+
+func TestEncode(t *testing.T) {
+	defer func() { *_algo, *_encoding = "md5", "hex" }()
+
+	for _, env := range []struct {
+		algo     string
+		encoding string
+		sum      []byte
+		result   string
+	}{
+		{"sha256", "hex", []byte{0x12, 0xab}, "12ab"},
+		{"sha256", "oci", []byte{0x12, 0xab}, "sha256:12ab"},
+		{"sha512/256", "oci", []byte{0x12, 0xab}, "sha512-256:12ab"},
+	} {
+		a := assert.New(t)
+		*_algo, *_encoding = env.algo, env.encoding
+		a.Equalf(env.result, (&node{sum: env.sum}).encode(), "%+v", env)
+
+		sum, err := decodeDigest(env.result)
+		a.NoErrorf(err, "%+v", env)
+		a.Equalf(env.sum, sum, "%+v", env)
+	}
+}
@@ -0,0 +1,190 @@
+// progress.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Refresh interval of the live progress line.
+const refreshInterval = 500 * time.Millisecond
+
+// The progress of the current run. Its counters are updated by the digester
+// goroutines and the totals are updated by the pre-scan goroutine.
+var meter = &progress{start: time.Now()}
+
+// progress tracks how many files and bytes have been hashed. It can output
+// a live progress line or a one-off status snapshot.
+type progress struct {
+	files      int64 // Number of files have been hashed
+	bytes      int64 // Number of bytes have been hashed
+	totalFiles int64 // Number of files found by the pre-scan
+	totalBytes int64 // Number of bytes found by the pre-scan
+	scanned    int32 // Reports whether the pre-scan has done
+
+	start time.Time
+	mu    sync.Mutex // Protects the following fields
+	live  bool       // Reports whether the live progress line is enabled
+	drawn bool       // Reports whether the progress line is on the screen
+	stop  trigger
+}
+
+// read() adds the number of bytes have been hashed.
+func (p *progress) read(n int) {
+	atomic.AddInt64(&p.bytes, int64(n))
+}
+
+// done() increases the number of files have been hashed.
+func (p *progress) done() {
+	atomic.AddInt64(&p.files, 1)
+}
+
+// counted() checks whether the node is counted in the total number of files,
+// which are regular files found by the pre-scan. Members of an archive are not
+// counted, but the archive itself is.
+func (n *node) counted() bool {
+	return n.FileInfo != nil && n.container == "" && (n.isregular() || n.packed)
+}
+
+// run() enables the live progress line and starts the pre-scan of the roots
+// which computes the total size for estimating the remaining time.
+func (p *progress) run(roots []string) {
+	p.live, p.stop = true, make(trigger)
+	go p.prescan(roots)
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.draw()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// prescan() traverses the roots like walk() and accumulates the number and
+// the total size of regular files.
+func (p *progress) prescan(roots []string) {
+	var S = (&node{depth: -1}).nodes(roots)
+	for len(S) > 0 {
+		var top *node
+		top, S = S[len(S)-1], S[:len(S)-1]
+		if top.err != nil || (!(*_all) && isHidden(top.filename())) {
+			continue
+		}
+
+		if top.depth < *_depth && top.isdir() {
+			S = append(S, top.children()...)
+		}
+
+		if top.isregular() {
+			atomic.AddInt64(&p.totalFiles, 1)
+			atomic.AddInt64(&p.totalBytes, top.size())
+		}
+	}
+	atomic.StoreInt32(&p.scanned, 1)
+}
+
+// draw() redraws the live progress line on the standard error.
+func (p *progress) draw() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.live {
+		fprintf(stderr, "\r\033[K%s", p)
+		p.drawn = true
+	}
+}
+
+// printf() erases the live progress line and then outputs to the w io.Writer.
+func (p *progress) printf(w io.Writer, format string, a ...interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.erase()
+	fprintf(w, format, a...)
+}
+
+// erase() erases the live progress line. NOTE: It must be called with the
+// mutex held.
+func (p *progress) erase() {
+	if p.drawn {
+		fprintf(stderr, "\r\033[K")
+		p.drawn = false
+	}
+}
+
+// close() disables the live progress line.
+func (p *progress) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.live {
+		close(p.stop)
+		p.live = false
+		p.erase()
+	}
+}
+
+// snapshot() outputs the current status to the standard error.
+func (p *progress) snapshot() {
+	p.printf(stderr, "go-hash: %s\n", p)
+}
+
+// String() returns the string form of the progress.
+func (p *progress) String() string {
+	var (
+		files   = atomic.LoadInt64(&p.files)
+		bytes   = atomic.LoadInt64(&p.bytes)
+		elapsed = time.Since(p.start)
+		speed   = float64(bytes) / elapsed.Seconds()
+	)
+
+	if atomic.LoadInt32(&p.scanned) == 0 {
+		return sprintf("%d files, %s, %s/s", files, humanize(bytes), humanize(int64(speed)))
+	}
+
+	var (
+		totalFiles = atomic.LoadInt64(&p.totalFiles)
+		totalBytes = atomic.LoadInt64(&p.totalBytes)
+		eta        = "--:--:--"
+	)
+
+	if speed > 0 && totalBytes >= bytes {
+		remain := time.Duration(float64(totalBytes-bytes)/speed) * time.Second
+		eta = sprintf("%02d:%02d:%02d", int(remain.Hours()), int(remain.Minutes())%60, int(remain.Seconds())%60)
+	}
+
+	return sprintf("%d/%d files, %s/%s, %s/s, ETA %s", files, totalFiles,
+		humanize(bytes), humanize(totalBytes), humanize(int64(speed)), eta)
+}
+
+// humanize() returns the human-readable form of a number of bytes.
+func humanize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// isTerminal() checks whether the file is a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,94 @@
+// progress_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"archive/zip"
+	"crypto/md5"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProgressString(t *testing.T) {
+	for _, env := range []struct {
+		p      *progress
+		result string
+	}{
+		{
+			p:      &progress{files: 3, bytes: 2048, start: time.Now().Add(-time.Second)},
+			result: "3 files, 2.0 KiB, ",
+		},
+		{
+			p: &progress{
+				files:      3,
+				bytes:      1 << 20,
+				totalFiles: 10,
+				totalBytes: 11 << 20,
+				scanned:    1,
+				start:      time.Now().Add(-time.Second),
+			},
+			result: "3/10 files, 1.0 MiB/11.0 MiB, ",
+		},
+	} {
+		a := assert.New(t)
+		a.Truef(strings.HasPrefix(env.p.String(), env.result), "%+v", env)
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	for _, env := range []struct {
+		n      int64
+		result string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{10 << 20, "10.0 MiB"},
+		{3 << 40, "3.0 TiB"},
+	} {
+		a := assert.New(t)
+		a.Equalf(env.result, humanize(env.n), "%+v", env)
+	}
+}
+
+func TestCounted(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "progress")
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "a"), []byte("Hello, World!\n"), 0644)
+	zf, _ := os.Create(filepath.Join(dir, "c.zip"))
+	zw := zip.NewWriter(zf)
+	for _, name := range []string{"x", "y"} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(name))
+	}
+	zw.Close()
+	zf.Close()
+
+	// Hard links to the same inode are counted separately like the pre-scan.
+	total := int64(2)
+	if os.Link(filepath.Join(dir, "a"), filepath.Join(dir, "b")) == nil {
+		total++
+	}
+
+	archive, factory := *_archive, creator
+	defer func() { *_archive, creator = archive, factory }()
+	*_archive, creator = true, md5.New
+
+	before := atomic.LoadInt64(&meter.files)
+	for range digester(make(trigger), walk(make(trigger), []string{dir})) {
+	}
+	assert.Equal(t, total, atomic.LoadInt64(&meter.files)-before)
+}
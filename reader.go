@@ -0,0 +1,47 @@
+// reader.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"io"
+	"os"
+)
+
+// A read backend wraps an opened file to a reader which is used by the digester
+// to read the file. If the backend is unsupported for the file, it returns an
+// error and the file will be read by plain reads instead. A reader had better
+// implement io.Seeker and io.ReaderAt, otherwise checkpoint and incremental
+// states can't be restored.
+type backend func(f *os.File) (io.ReadCloser, error)
+
+// The read backend selected by the reader option. It's nil when plain reads
+// are used.
+var opener backend
+
+// openFile() opens the named file for reading by using the selected backend.
+// It falls back to plain reads if the backend is unsupported.
+func openFile(name string) (io.ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sparse files are read by plain reads, but their holes won't be read
+	// from the disk.
+	if opener == nil {
+		if r, err := openSparse(f); err == nil {
+			return r, nil
+		}
+		return f, nil
+	}
+
+	if r, err := opener(f); err == nil {
+		return r, nil
+	}
+	return f, nil
+}
@@ -0,0 +1,580 @@
+// reader_linux.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build linux
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// backends variable specifies all read backends supported by this tool. (For Linux)
+var backends = map[string]backend{
+	"read":     nil,
+	"mmap":     openMmap,
+	"direct":   openDirect,
+	"io_uring": openURing,
+}
+
+/* mmap */
+
+// The error returned when a mapped file is truncated while it's being read.
+var errTruncated = errorf("file was truncated while being read")
+
+// mmapReader reads a file which is mapped into the memory. Accessing pages
+// beyond the end of a truncated file raises SIGBUS, which is converted into
+// the errTruncated error instead of crashing the process.
+type mmapReader struct {
+	*bytes.Reader
+	f    *os.File
+	data []byte
+}
+
+// openMmap() maps the whole file into the memory.
+func openMmap(f *os.File) (io.ReadCloser, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &mmapReader{f: f}
+	if size := fi.Size(); size > 0 {
+		if int64(int(size)) != size {
+			return nil, errorf("file is too large to be mapped")
+		}
+
+		if m.data, err = syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED); err != nil {
+			return nil, err
+		}
+		syscall.Madvise(m.data, syscall.MADV_SEQUENTIAL)
+	}
+	m.Reader = bytes.NewReader(m.data)
+	return m, nil
+}
+
+// Read() reads the mapped file into p.
+func (m *mmapReader) Read(p []byte) (n int, err error) {
+	defer m.guard(&err)
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	return m.Reader.Read(p)
+}
+
+// ReadAt() reads len(p) bytes from the mapped file starting at the offset.
+func (m *mmapReader) ReadAt(p []byte, offset int64) (n int, err error) {
+	defer m.guard(&err)
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	return m.Reader.ReadAt(p, offset)
+}
+
+// WriteTo() writes the rest of the mapped file to w.
+func (m *mmapReader) WriteTo(w io.Writer) (n int64, err error) {
+	defer m.guard(&err)
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	return m.Reader.WriteTo(w)
+}
+
+// guard() recovers from the fault of accessing the mapped memory and stores
+// the errTruncated error to err. It must be deferred directly.
+func (m *mmapReader) guard(err *error) {
+	if r := recover(); r != nil {
+		if _, ok := r.(runtime.Error); !ok {
+			panic(r)
+		}
+		*err = errTruncated
+	}
+}
+
+// Fd() returns the file descriptor of the mapped file.
+func (m *mmapReader) Fd() uintptr {
+	return m.f.Fd()
+}
+
+// Close() unmaps the file and closes it.
+func (m *mmapReader) Close() error {
+	if m.data != nil {
+		syscall.Munmap(m.data)
+	}
+	return m.f.Close()
+}
+
+/* O_DIRECT */
+
+// The offset, the length and the buffer address of O_DIRECT reads must be
+// aligned to the logical block size. 4096 is a safe choice for most devices.
+const directAlign = 4096
+
+// directReader reads a file opened with O_DIRECT, which bypasses the page cache.
+type directReader struct {
+	f      *os.File
+	buf    []byte // Aligned buffer
+	data   []byte // Unread data in the buffer
+	offset int64  // Offset of the unread data
+}
+
+// openDirect() reopens the file with O_DIRECT. The original file will be
+// closed if the O_DIRECT file can be read.
+func openDirect(f *os.File) (io.ReadCloser, error) {
+	df, err := os.OpenFile(f.Name(), os.O_RDONLY|syscall.O_DIRECT, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &directReader{f: df, buf: alignedBuffer(bufferSize)}
+	if _, err = d.pread(d.buf[:directAlign], 0); err != nil {
+		df.Close() // Some file systems (tmpfs) don't support O_DIRECT.
+		return nil, err
+	}
+
+	f.Close()
+	return d, nil
+}
+
+// Read() reads up to len(p) bytes from the file.
+func (d *directReader) Read(p []byte) (int, error) {
+	if len(d.data) == 0 {
+		aligned := d.offset &^ (directAlign - 1)
+		n, err := d.pread(d.buf, aligned)
+		if err != nil {
+			return 0, err
+		}
+
+		if skip := int(d.offset - aligned); n > skip {
+			d.data = d.buf[skip:n]
+		} else {
+			return 0, io.EOF
+		}
+	}
+
+	n := copy(p, d.data)
+	d.data = d.data[n:]
+	d.offset += int64(n)
+	return n, nil
+}
+
+// ReadAt() reads len(p) bytes from the file starting at the offset.
+func (d *directReader) ReadAt(p []byte, offset int64) (int, error) {
+	var (
+		aligned = offset &^ (directAlign - 1)
+		skip    = int(offset - aligned)
+		size    = (skip + len(p) + directAlign - 1) &^ (directAlign - 1)
+		buf     = alignedBuffer(size)
+	)
+
+	n, err := d.pread(buf, aligned)
+	if err != nil {
+		return 0, err
+	}
+
+	if n -= skip; n < 0 {
+		n = 0
+	}
+	if n = copy(p, buf[skip:skip+n]); n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Seek() sets the offset for the next Read.
+func (d *directReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += d.offset
+	case io.SeekEnd:
+		fi, err := d.f.Stat()
+		if err != nil {
+			return 0, err
+		}
+		offset += fi.Size()
+	}
+
+	if offset < 0 {
+		return 0, errorf("negative offset")
+	}
+	d.offset, d.data = offset, nil
+	return offset, nil
+}
+
+// Close() closes the file.
+func (d *directReader) Close() error {
+	return d.f.Close()
+}
+
+// pread() reads the file at the aligned offset until the buffer is full or
+// EOF. It returns the number of bytes read.
+func (d *directReader) pread(buf []byte, offset int64) (int, error) {
+	var total int
+	for total < len(buf) {
+		n, err := syscall.Pread(int(d.f.Fd()), buf[total:], offset+int64(total))
+		if err == syscall.EINTR {
+			continue
+		} else if err != nil {
+			return 0, err
+		} else if n == 0 || n%directAlign != 0 {
+			return total + n, nil // EOF
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// alignedBuffer() allocates a buffer whose address is aligned for O_DIRECT.
+func alignedBuffer(size int) []byte {
+	buf := make([]byte, size+directAlign)
+	shift := int(uintptr(unsafe.Pointer(&buf[0])) & (directAlign - 1))
+	if shift != 0 {
+		shift = directAlign - shift
+	}
+	return buf[shift : shift+size]
+}
+
+/* io_uring */
+
+const (
+	ioringOpRead         = 22
+	ioringEnterGetevents = 1
+	ioringFeatSingleMmap = 1
+
+	ioringOffSQRing = 0
+	ioringOffCQRing = 0x8000000
+	ioringOffSQEs   = 0x10000000
+
+	uringDepth     = 8         // Number of reads in flight
+	uringChunkSize = 256 << 10 // Number of bytes per read
+)
+
+// Parameters of io_uring_setup(2).
+type uringParams struct {
+	sqEntries    uint32
+	cqEntries    uint32
+	flags        uint32
+	sqThreadCPU  uint32
+	sqThreadIdle uint32
+	features     uint32
+	wqFd         uint32
+	resv         [3]uint32
+	sqOff        struct{ head, tail, ringMask, ringEntries, flags, dropped, array, resv1, resv2, resv3 uint32 }
+	cqOff        struct{ head, tail, ringMask, ringEntries, overflow, cqes, flags, resv1, resv2, resv3 uint32 }
+}
+
+// Submission queue entry.
+type uringSQE struct {
+	opcode   uint8
+	flags    uint8
+	ioprio   uint16
+	fd       int32
+	off      uint64
+	addr     uint64
+	len      uint32
+	rwFlags  uint32
+	userData uint64
+	pad      [3]uint64
+}
+
+// Completion queue entry.
+type uringCQE struct {
+	userData uint64
+	res      int32
+	flags    uint32
+}
+
+// A read request in flight or completed.
+type uringChunk struct {
+	offset int64
+	length int
+	res    int
+	done   bool
+}
+
+// uringReader reads a file by submitting batched reads to an io_uring instance.
+// Reads are submitted ahead of time, so the device is kept busy while the data
+// is being hashed.
+type uringReader struct {
+	f      *os.File
+	ring   int // io_uring file descriptor
+	params uringParams
+	sq     []byte
+	cq     []byte
+	sqes   []byte
+
+	size   int64 // File size
+	next   int64 // Offset of the next read to submit
+	offset int64 // Offset of the unread data
+	bufs   [uringDepth][]byte
+	chunks [uringDepth]uringChunk
+	queue  []int  // Slots in flight, sorted by offset
+	cur    int    // Slot of the unread data, -1 means none
+	data   []byte // Unread data
+}
+
+// openURing() sets up an io_uring instance for reading the file.
+func openURing(f *os.File) (io.ReadCloser, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	u := &uringReader{f: f, size: fi.Size(), cur: -1}
+	fd, _, errno := syscall.Syscall(sysIOURingSetup, uringDepth, uintptr(unsafe.Pointer(&u.params)), 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	u.ring = int(fd)
+
+	var (
+		p      = &u.params
+		sqSize = int(p.sqOff.array + p.sqEntries*4)
+		cqSize = int(p.cqOff.cqes + p.cqEntries*uint32(unsafe.Sizeof(uringCQE{})))
+	)
+
+	if p.features&ioringFeatSingleMmap != 0 && cqSize > sqSize {
+		sqSize = cqSize
+	}
+
+	if u.sq, err = syscall.Mmap(u.ring, ioringOffSQRing, sqSize, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE); err != nil {
+		return nil, u.release(err)
+	}
+
+	if p.features&ioringFeatSingleMmap != 0 {
+		u.cq = u.sq
+	} else if u.cq, err = syscall.Mmap(u.ring, ioringOffCQRing, cqSize, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE); err != nil {
+		return nil, u.release(err)
+	}
+
+	sqesSize := int(p.sqEntries) * int(unsafe.Sizeof(uringSQE{}))
+	if u.sqes, err = syscall.Mmap(u.ring, ioringOffSQEs, sqesSize, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE); err != nil {
+		return nil, u.release(err)
+	}
+
+	for i := range u.bufs {
+		u.bufs[i] = make([]byte, uringChunkSize)
+	}
+	return u, nil
+}
+
+// Read() reads up to len(p) bytes from the file.
+func (u *uringReader) Read(p []byte) (int, error) {
+	for len(u.data) == 0 {
+		u.cur = -1
+		if err := u.submit(); err != nil {
+			return 0, err
+		}
+
+		if len(u.queue) == 0 {
+			return 0, io.EOF
+		}
+
+		slot := u.queue[0]
+		if err := u.wait(slot); err != nil {
+			return 0, err
+		}
+		u.queue = u.queue[1:]
+
+		c := &u.chunks[slot]
+		if c.res < 0 {
+			u.drain()
+			return 0, syscall.Errno(-c.res)
+		}
+
+		// A short read means the file has been truncated or the kernel has
+		// read less than requested, restart from the end of the data.
+		if c.res < c.length {
+			u.drain()
+			if u.next = c.offset + int64(c.res); c.res == 0 {
+				u.size = u.next
+			}
+		}
+		u.cur, u.data = slot, u.bufs[slot][:c.res]
+	}
+
+	n := copy(p, u.data)
+	u.data = u.data[n:]
+	u.offset += int64(n)
+	return n, nil
+}
+
+// ReadAt() reads len(p) bytes from the file starting at the offset.
+func (u *uringReader) ReadAt(p []byte, offset int64) (int, error) {
+	return u.f.ReadAt(p, offset)
+}
+
+// Seek() sets the offset for the next Read.
+func (u *uringReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += u.offset
+	case io.SeekEnd:
+		offset += u.size
+	}
+
+	if offset < 0 {
+		return 0, errorf("negative offset")
+	}
+
+	u.drain()
+	u.next, u.offset, u.cur, u.data = offset, offset, -1, nil
+	return offset, nil
+}
+
+// Fd() returns the file descriptor of the file.
+func (u *uringReader) Fd() uintptr {
+	return u.f.Fd()
+}
+
+// Close() waits for reads in flight and releases the io_uring instance.
+func (u *uringReader) Close() error {
+	u.drain()
+	u.release(nil)
+	return u.f.Close()
+}
+
+// submit() submits reads to free slots until the end of the file.
+func (u *uringReader) submit() error {
+	var (
+		p       = &u.params
+		tail    = u.load(u.sq, p.sqOff.tail)
+		mask    = u.load(u.sq, p.sqOff.ringMask)
+		pending int
+	)
+
+	for slot := range u.chunks {
+		if u.next >= u.size {
+			break
+		}
+
+		if slot == u.cur || u.inflight(slot) {
+			continue
+		}
+
+		length := int64(uringChunkSize)
+		if u.size-u.next < length {
+			length = u.size - u.next
+		}
+
+		u.chunks[slot] = uringChunk{offset: u.next, length: int(length)}
+		idx := tail & mask
+		*(*uringSQE)(unsafe.Pointer(&u.sqes[uintptr(idx)*unsafe.Sizeof(uringSQE{})])) = uringSQE{
+			opcode:   ioringOpRead,
+			fd:       int32(u.f.Fd()),
+			off:      uint64(u.next),
+			addr:     uint64(uintptr(unsafe.Pointer(&u.bufs[slot][0]))),
+			len:      uint32(length),
+			userData: uint64(slot),
+		}
+		*(*uint32)(unsafe.Pointer(&u.sq[p.sqOff.array+idx*4])) = idx
+		tail++
+
+		u.queue = append(u.queue, slot)
+		u.next += length
+		pending++
+	}
+
+	if pending == 0 {
+		return nil
+	}
+	atomic.StoreUint32((*uint32)(unsafe.Pointer(&u.sq[p.sqOff.tail])), tail)
+	return u.enter(pending, 0)
+}
+
+// wait() waits until the read of the slot has completed.
+func (u *uringReader) wait(slot int) error {
+	for !u.chunks[slot].done {
+		if u.reap() == 0 {
+			if err := u.enter(0, 1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// drain() waits for all reads in flight and discards them.
+func (u *uringReader) drain() {
+	for _, slot := range u.queue {
+		if u.wait(slot) != nil {
+			break
+		}
+	}
+	u.queue = u.queue[:0]
+}
+
+// reap() consumes completion queue entries and returns the number of them.
+func (u *uringReader) reap() int {
+	var (
+		p    = &u.params
+		head = u.load(u.cq, p.cqOff.head)
+		tail = u.load(u.cq, p.cqOff.tail)
+		mask = u.load(u.cq, p.cqOff.ringMask)
+		n    int
+	)
+
+	for ; head != tail; head++ {
+		off := uintptr(p.cqOff.cqes) + uintptr(head&mask)*unsafe.Sizeof(uringCQE{})
+		cqe := (*uringCQE)(unsafe.Pointer(&u.cq[off]))
+		c := &u.chunks[cqe.userData]
+		c.res, c.done = int(cqe.res), true
+		n++
+	}
+	atomic.StoreUint32((*uint32)(unsafe.Pointer(&u.cq[p.cqOff.head])), head)
+	return n
+}
+
+// enter() calls io_uring_enter(2) to submit requests and wait for completions.
+func (u *uringReader) enter(submit, complete int) error {
+	var flags uintptr
+	if complete > 0 {
+		flags = ioringEnterGetevents
+	}
+
+	for {
+		_, _, errno := syscall.Syscall6(sysIOURingEnter, uintptr(u.ring), uintptr(submit), uintptr(complete), flags, 0, 0)
+		if errno == syscall.EINTR {
+			continue
+		} else if errno != 0 {
+			return errno
+		}
+		return nil
+	}
+}
+
+// inflight() checks whether the slot is in the queue.
+func (u *uringReader) inflight(slot int) bool {
+	for _, s := range u.queue {
+		if s == slot {
+			return true
+		}
+	}
+	return false
+}
+
+// load() atomically loads an uint32 value from the ring at the offset.
+func (u *uringReader) load(ring []byte, offset uint32) uint32 {
+	return atomic.LoadUint32((*uint32)(unsafe.Pointer(&ring[offset])))
+}
+
+// release() unmaps rings and closes the io_uring file descriptor. It returns
+// the err parameter, which is convenient for error handling.
+func (u *uringReader) release(err error) error {
+	if u.sqes != nil {
+		syscall.Munmap(u.sqes)
+	}
+	if u.cq != nil && len(u.cq) > 0 && &u.cq[0] != &u.sq[0] {
+		syscall.Munmap(u.cq)
+	}
+	if u.sq != nil {
+		syscall.Munmap(u.sq)
+	}
+	syscall.Close(u.ring)
+	return err
+}
@@ -0,0 +1,119 @@
+// reader_linux_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build linux
+
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackends(t *testing.T) {
+	for _, size := range []int{0, 1, 4095, 4096, 4097, uringChunkSize*uringDepth + 12345, 3*bufferSize + 1} {
+		content := make([]byte, size)
+		rand.Read(content)
+		path := tempFile(content)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		for name, open := range backends {
+			if open == nil {
+				continue
+			}
+
+			f, _ := os.Open(path)
+			r, err := open(f)
+			if err != nil {
+				t.Logf("backend '%s' is unsupported: %s", name, err)
+				f.Close()
+				continue
+			}
+
+			a := assert.New(t)
+			data, err := ioutil.ReadAll(r)
+			a.NoErrorf(err, "%s %d", name, size)
+			a.Truef(bytes.Equal(content, data), "%s %d", name, size)
+
+			if size > 100 {
+				offset := int64(size / 3)
+				_, err = r.(io.Seeker).Seek(offset, io.SeekStart)
+				a.NoErrorf(err, "%s %d", name, size)
+				data, err = ioutil.ReadAll(r)
+				a.NoErrorf(err, "%s %d", name, size)
+				a.Truef(bytes.Equal(content[offset:], data), "%s %d", name, size)
+
+				block := make([]byte, 100)
+				n, err := r.(io.ReaderAt).ReadAt(block, offset+1)
+				a.Equalf(100, n, "%s %d", name, size)
+				a.NoErrorf(err, "%s %d", name, size)
+				a.Truef(bytes.Equal(content[offset+1:offset+101], block), "%s %d", name, size)
+			}
+			a.NoErrorf(r.Close(), "%s %d", name, size)
+		}
+	}
+}
+
+func TestMmapTruncated(t *testing.T) {
+	path := tempFile(make([]byte, 1<<20))
+	defer os.RemoveAll(filepath.Dir(path))
+
+	f, _ := os.Open(path)
+	r, err := openMmap(f)
+	if err != nil {
+		f.Close()
+		t.Skipf("mmap is unsupported: %s", err)
+	}
+	defer r.Close()
+
+	// Pages beyond the end of the truncated file can't be accessed.
+	os.Truncate(path, 0)
+	_, err = ioutil.ReadAll(r)
+	assert.Equal(t, errTruncated, err)
+	_, err = r.(io.ReaderAt).ReadAt(make([]byte, 100), 1<<19)
+	assert.Equal(t, errTruncated, err)
+}
+
+func BenchmarkBackends(b *testing.B) {
+	content := make([]byte, 64<<20)
+	rand.Read(content)
+	path := tempFile(content)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	for name, open := range backends {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(content)))
+			opener = open
+			defer func() { opener = nil }()
+			buf := make([]byte, bufferSize)
+			for i := 0; i < b.N; i++ {
+				r, err := openFile(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				io.CopyBuffer(ioutil.Discard, struct{ io.Reader }{r}, buf)
+				r.Close()
+			}
+		})
+	}
+}
+
+// tempFile() creates a temporary file in a new temporary directory, which
+// should be removed by the caller. Backends unsupported by the file system
+// (like O_DIRECT on tmpfs) are skipped.
+func tempFile(content []byte) string {
+	dir, _ := ioutil.TempDir("", "reader")
+	path := filepath.Join(dir, "reader.tmp")
+	ioutil.WriteFile(path, content, 0644)
+	return path
+}
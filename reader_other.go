@@ -0,0 +1,19 @@
+// reader_other.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build !linux
+
+package main
+
+// backends variable specifies all read backends supported by this tool. All
+// of them fall back to plain reads. (For Other Systems)
+var backends = map[string]backend{
+	"read":     nil,
+	"mmap":     nil,
+	"direct":   nil,
+	"io_uring": nil,
+}
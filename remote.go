@@ -0,0 +1,137 @@
+// remote.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Schemes of URLs which can be specified as root files.
+var schemes = []string{"http://", "https://", "file://"}
+
+// HTTP client used to fetch remote files. It follows at most 10 redirects.
+var client = &http.Client{}
+
+// isURL() checks whether the string is a http(s) or file URL.
+func isURL(str string) bool {
+	for _, scheme := range schemes {
+		if len(str) >= len(scheme) && strings.EqualFold(str[:len(scheme)], scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// remote() inits a node instance by using a URL and returns itself. A file URL
+// is converted to the local path, other URLs will be fetched when computing
+// the digest. If something wrong, it will store the error to the err field.
+func (n *node) remote(rawurl string) *node {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		n.path, n.err = rawurl, err
+		return n
+	}
+
+	if u.Scheme == "file" {
+		if u.Host != "" && u.Host != "localhost" {
+			n.path, n.err = rawurl, errorf("unsupported file URL host '%s'", u.Host)
+			return n
+		}
+		return n.init(filepath.FromSlash(u.Path))
+	}
+
+	n.path = rawurl
+	n.source = func() (io.ReadCloser, error) { return fetch(rawurl) }
+	return n
+}
+
+// fetch() sends a GET request to the URL and returns the response body which
+// will be streamed into the hash. The request will be canceled if connecting,
+// receiving the response header or any read of the body can't be done within
+// the timeout.
+func fetch(rawurl string) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", rawurl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "go-hash/"+binary)
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		r           = &remoteReader{cancel: cancel, timeout: *_timeout}
+	)
+
+	if r.timeout > 0 {
+		r.timer = time.AfterFunc(r.timeout, r.expire)
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		r.Close()
+		return nil, r.check(err)
+	}
+
+	r.body = resp.Body
+	if resp.StatusCode != http.StatusOK {
+		r.Close()
+		return nil, errorf("unexpected status '%s'", resp.Status)
+	}
+	return r, nil
+}
+
+// remoteReader reads the response body of a remote file.
+type remoteReader struct {
+	body    io.ReadCloser
+	cancel  context.CancelFunc
+	timer   *time.Timer
+	timeout time.Duration
+	expired int32
+}
+
+// Read() reads the response body and resets the timer of the timeout.
+func (r *remoteReader) Read(b []byte) (int, error) {
+	n, err := r.body.Read(b)
+	if r.timer != nil {
+		r.timer.Reset(r.timeout)
+	}
+	return n, r.check(err)
+}
+
+// Close() stops the timer, cancels the request and closes the response body.
+func (r *remoteReader) Close() error {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.cancel()
+
+	if r.body != nil {
+		return r.body.Close()
+	}
+	return nil
+}
+
+// expire() cancels the request when the timeout is reached.
+func (r *remoteReader) expire() {
+	atomic.StoreInt32(&r.expired, 1)
+	r.cancel()
+}
+
+// check() replaces the error caused by the cancellation with a timeout error.
+func (r *remoteReader) check(err error) error {
+	if err != nil && err != io.EOF && atomic.LoadInt32(&r.expired) == 1 {
+		return errorf("timeout after %s", r.timeout)
+	}
+	return err
+}
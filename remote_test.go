@@ -0,0 +1,111 @@
+// remote_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"crypto/md5"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIsURL(t *testing.T) {
+	for _, env := range []struct {
+		str    string
+		result bool
+	}{
+		{"", false},
+		{"http", false},
+		{"http://", true},
+		{"http://example.com/a.txt", true},
+		{"HTTPS://example.com/a.txt", true},
+		{"file:///tmp/a.txt", true},
+		{"ftp://example.com/a.txt", false},
+		{"/tmp/http://a.txt", false},
+		{"http:/a.txt", false},
+	} {
+		a := assert.New(t)
+		a.Equalf(env.result, isURL(env.str), "%+v", env)
+	}
+}
+
+func TestRemote(t *testing.T) {
+	content := []byte("Hello, World!")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/file", func(w http.ResponseWriter, r *http.Request) { w.Write(content) })
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/file", http.StatusFound)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content[:5])
+		w.(http.Flusher).Flush()
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	f, _ := ioutil.TempFile("", "remote")
+	defer os.Remove(f.Name())
+	f.Write(content)
+	f.Close()
+
+	*_timeout = 100 * time.Millisecond
+	defer func() { *_timeout = 30 * time.Second }()
+
+	sum := md5.Sum(content)
+	for _, env := range []struct {
+		url  string
+		path string
+		sum  []byte
+		ok   bool
+	}{
+		{server.URL + "/file", server.URL + "/file", sum[:], true},
+		{server.URL + "/redirect", server.URL + "/redirect", sum[:], true},
+		{server.URL + "/missing", server.URL + "/missing", nil, false},
+		{server.URL + "/slow", server.URL + "/slow", nil, false},
+		{"file://" + filepath.ToSlash(f.Name()), f.Name(), sum[:], true},
+		{"file://example.com/a.txt", "file://example.com/a.txt", nil, false},
+		{"file:///not/exist", "/not/exist", nil, false},
+	} {
+		a := assert.New(t)
+		n := (&node{}).remote(env.url)
+		a.Equalf(env.path, n.path, "%+v", env)
+
+		if n.err == nil {
+			n.sum, n.err = n.digest(make(trigger), md5.New())
+		}
+		a.Equalf(env.ok, n.err == nil, "%+v %s", env, n.err)
+		a.Equalf(env.sum, n.sum, "%+v", env)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	defer func() { expected = nil }()
+
+	for _, env := range []struct {
+		expected []byte
+		sum      []byte
+		ok       bool
+	}{
+		{nil, []byte{0x12, 0x34}, true},
+		{[]byte{0x12, 0x34}, []byte{0x12, 0x34}, true},
+		{[]byte{0x12, 0x34}, []byte{0x12, 0x35}, false},
+	} {
+		a := assert.New(t)
+		expected = env.expected
+		a.Equalf(env.ok, (&node{sum: env.sum}).verify() == nil, "%+v", env)
+	}
+}
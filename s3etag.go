@@ -0,0 +1,218 @@
+// s3etag.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// The part size of multipart ETags specified by the part_size option.
+var partSize int64 = 8 << 20
+
+// Part sizes used by popular S3 clients, they're tried when detecting the part
+// size of a multipart ETag.
+var commonPartSizes = []int64{5 << 20, 8 << 20, 15 << 20, 16 << 20, 64 << 20, 100 << 20, 128 << 20, 256 << 20, 512 << 20, 1 << 30}
+
+// A detector is a hash.Hash whose parameters can be detected from the size of
+// the data and the expected digest before writing.
+type detector interface {
+	hash.Hash
+	detect(size int64, want []byte)
+}
+
+// s3ETag computes ETags of objects in S3-compatible storage. If an object has
+// only one part, its ETag is the md5 digest. Otherwise it's the md5 digest of
+// the concatenated md5 digests of parts suffixed with '-N', where N is the
+// number of parts. The sum consists of the digest and the big-endian number of
+// parts, which is zero for single-part ETags.
+//
+// The part size is unknown when verifying a multipart ETag, so ETags of all
+// candidate part sizes are computed simultaneously and the matched one will
+// be returned by Sum().
+type s3ETag struct {
+	md5   factory // Creates hash.Hash instances computing md5 digests.
+	parts []*s3Parts
+	want  []byte
+}
+
+// s3Parts computes the ETag of the specified part size.
+type s3Parts struct {
+	size  int64     // The size of a part.
+	md5   factory   // Creates hash.Hash instances computing md5 digests.
+	h     hash.Hash // The md5 digest of the current part.
+	n     int64     // The number of bytes written to the current part.
+	sums  []byte    // Digests of complete parts.
+	multi bool      // Returns a multipart ETag even if there is only one part.
+}
+
+// s3Factory specifies how to create a hash.Hash instance computing md5 digests
+// of parts and ETags.
+type s3Factory func() hash.Hash
+
+// normalize() converts a s3Factory instance to the corresponded factory instance.
+func (sf s3Factory) normalize() factory {
+	return func() hash.Hash {
+		e := &s3ETag{md5: factory(sf)}
+		e.Reset()
+		return e
+	}
+}
+
+// detect() selects candidate part sizes when the expected ETag is multipart. A
+// candidate part size should split the data into the same number of parts. The
+// part_size option is preferred, then common part sizes are tried, and the last
+// candidates are the smallest part size in MiB and the size of the data.
+func (e *s3ETag) detect(size int64, want []byte) {
+	e.Reset()
+	if len(want) != e.Size() {
+		return
+	}
+	e.want = want
+
+	count := int64(partCount(want))
+	if count == 0 {
+		return
+	}
+
+	var (
+		sizes = append([]int64{partSize}, commonPartSizes...)
+		mib   = int64(1 << 20)
+		seen  = make(map[int64]bool)
+	)
+	sizes = append(sizes, (size+count*mib-1)/(count*mib)*mib, size)
+
+	e.parts = nil
+	for _, ps := range sizes {
+		if ps > 0 && !seen[ps] && (size+ps-1)/ps == count {
+			seen[ps] = true
+			e.parts = append(e.parts, e.newParts(ps, true))
+		}
+	}
+
+	if len(e.parts) == 0 {
+		e.parts = []*s3Parts{e.newParts(partSize, true)}
+	}
+}
+
+// Write() writes data to parts of all candidate part sizes.
+func (e *s3ETag) Write(p []byte) (int, error) {
+	for _, ps := range e.parts {
+		ps.write(p)
+	}
+	return len(p), nil
+}
+
+// Sum() appends the ETag matched the expected one to b and returns the resulting
+// slice, the ETag of the first candidate part size is returned if none matched.
+func (e *s3ETag) Sum(b []byte) []byte {
+	for _, ps := range e.parts[1:] {
+		if sum := ps.sum(); bytes.Equal(sum, e.want) {
+			return append(b, sum...)
+		}
+	}
+	return append(b, e.parts[0].sum()...)
+}
+
+// Reset() resets the hash to its initial state, the part size is specified by
+// the part_size option.
+func (e *s3ETag) Reset() {
+	e.parts, e.want = []*s3Parts{e.newParts(partSize, false)}, nil
+}
+
+// newParts() creates parts of the size. If the multi parameter is true, the
+// ETag is always multipart.
+func (e *s3ETag) newParts(size int64, multi bool) *s3Parts {
+	return &s3Parts{size: size, md5: e.md5, h: e.md5(), multi: multi}
+}
+
+// Size() returns the number of bytes Sum() will return.
+func (e *s3ETag) Size() int {
+	return md5.Size + 4
+}
+
+// BlockSize() returns the block size of md5.
+func (e *s3ETag) BlockSize() int {
+	return md5.BlockSize
+}
+
+// write() writes data to the current part, the digest of the part is computed
+// when it's full and more data is written.
+func (ps *s3Parts) write(p []byte) {
+	for len(p) > 0 {
+		if ps.n == ps.size {
+			ps.sums, ps.n = ps.h.Sum(ps.sums), 0
+			ps.h.Reset()
+		}
+
+		n := ps.size - ps.n
+		if n > int64(len(p)) {
+			n = int64(len(p))
+		}
+		ps.h.Write(p[:n])
+		ps.n, p = ps.n+n, p[n:]
+	}
+}
+
+// sum() returns the ETag of parts.
+func (ps *s3Parts) sum() []byte {
+	sums := ps.h.Sum(append([]byte(nil), ps.sums...))
+	count := len(sums) / md5.Size
+	if count == 1 && !ps.multi {
+		return append(sums, 0, 0, 0, 0)
+	}
+
+	h := ps.md5()
+	h.Write(sums)
+	return appendCount(h.Sum(nil), uint32(count))
+}
+
+// encodeETag() encodes the ETag like 'd41d8cd98f00b204e9800998ecf8427e' or
+// 'e9a0e6ab3d5fbd6bc6ab6e2c7a5a2b6b-3'.
+func encodeETag(sum []byte) string {
+	if count := partCount(sum); count > 0 {
+		return sprintf("%x-%d", sum[:md5.Size], count)
+	}
+	return hex.EncodeToString(sum[:md5.Size])
+}
+
+// decodeETag() decodes an ETag, it's the reverse operation of the encodeETag()
+// function. The ETag can be enclosed in double quotes like HTTP headers.
+func decodeETag(str string) ([]byte, error) {
+	var (
+		strs  = strings.SplitN(strings.Trim(str, `"`), "-", 2)
+		count uint64
+	)
+
+	sum, err := hex.DecodeString(strs[0])
+	if err != nil || len(sum) != md5.Size {
+		return nil, errorf("invalid ETag '%s'", str)
+	}
+
+	if len(strs) == 2 {
+		if count, err = strconv.ParseUint(strs[1], 10, 32); err != nil || count == 0 {
+			return nil, errorf("invalid ETag '%s'", str)
+		}
+	}
+	return appendCount(sum, uint32(count)), nil
+}
+
+// appendCount() appends the big-endian number of parts to the digest.
+func appendCount(sum []byte, count uint32) []byte {
+	return append(sum, byte(count>>24), byte(count>>16), byte(count>>8), byte(count))
+}
+
+// partCount() returns the number of parts in the sum.
+func partCount(sum []byte) uint32 {
+	b := sum[md5.Size:]
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
@@ -0,0 +1,71 @@
+// s3etag_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"crypto/md5"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestS3ETag(t *testing.T) {
+	sizes := commonPartSizes
+	defer func() { *_algo, partSize, commonPartSizes = "md5", 8<<20, sizes }()
+	*_algo, commonPartSizes = "s3etag", []int64{3, 5}
+
+	// The ETag of parts computed by the definition.
+	etag := func(data string, size int) string {
+		var sums []byte
+		for i := 0; i < len(data); i += size {
+			j := i + size
+			if j > len(data) {
+				j = len(data)
+			}
+			sum := md5.Sum([]byte(data[i:j]))
+			sums = append(sums, sum[:]...)
+		}
+		return sprintf("%x-%d", md5.Sum(sums), (len(data)+size-1)/size)
+	}
+
+	for _, env := range []struct {
+		data   string
+		size   int64
+		want   string
+		result string
+	}{
+		{"", 4, "", "d41d8cd98f00b204e9800998ecf8427e"},
+		{"abcd", 4, "", "e2fc714c4727ee9395f324cd2e7f331f"},
+		{"abcde", 4, "", etag("abcde", 4)},
+		{"abcdefghijkl", 4, "", etag("abcdefghijkl", 4)},
+		{"abcdefghijkl", 4, etag("abcdefghijkl", 5), etag("abcdefghijkl", 5)},
+		{"abcdefghijkl", 4, etag("abcdefghijkl", 12), etag("abcdefghijkl", 12)},
+		{"abcdefghijkl", 4, "\"" + etag("abcdefghijkl", 3) + "\"", etag("abcdefghijkl", 3)},
+		{"abcdefghijkl", 4, "0123456789abcdef0123456789abcdef-4", etag("abcdefghijkl", 3)},
+		{"abcdefghijkl", 4, "0123456789abcdef0123456789abcdef-7", etag("abcdefghijkl", 4)},
+	} {
+		a := assert.New(t)
+		partSize = env.size
+
+		var want []byte
+		if env.want != "" {
+			var err error
+			want, err = decodeDigest(env.want)
+			a.NoErrorf(err, "%+v", env)
+		}
+
+		h := factories["s3etag"]().(detector)
+		h.detect(int64(len(env.data)), want)
+		h.Write([]byte(env.data))
+		a.Equalf(env.result, encodeDigest(h.Sum(nil)), "%+v", env)
+	}
+
+	for _, str := range []string{"0123", "0123456789abcdef0123456789abcdef-0", "0123456789abcdef0123456789abcdef-x"} {
+		_, err := decodeETag(str)
+		assert.Errorf(t, err, "%s", str)
+	}
+}
@@ -0,0 +1,116 @@
+// sidecar.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The extension of sidecar files, it's determined by the hash algorithm, like
+// '.sha256' or '.md5'.
+var sidecarExt string
+
+// The error of a node whose digest is different from the expected digest
+// recorded in a checksum file.
+var errMismatch = errors.New("digest mismatch")
+
+// sidecar() handles a file for the sidecar option when walking. In the write
+// mode, sidecar files will be ignored. In the verify mode, the expected digest
+// of a file is loaded from its sidecar file, and files without sidecar files
+// will be ignored. It returns nil when the file should be ignored.
+func (n *node) sidecar() *node {
+	if strings.HasSuffix(n.path, sidecarExt) {
+		// The corresponding file will be checked when it's walked. But if
+		// it doesn't exist, reports the error here.
+		target := strings.TrimSuffix(n.path, sidecarExt)
+		if _, err := os.Lstat(target); *_sidecar == "verify" && os.IsNotExist(err) {
+			return (&node{depth: n.depth}).init(target)
+		}
+		return nil
+	}
+
+	if *_sidecar == "verify" {
+		data, err := ioutil.ReadFile(n.path + sidecarExt)
+		if os.IsNotExist(err) {
+			return nil
+		} else if err == nil {
+			n.want, _, err = parseSum(strings.SplitN(string(data), "\n", 2)[0])
+		}
+		n.err = err
+	}
+	return n
+}
+
+// sidecars() writes a sidecar file next to each file whose digest has been
+// computed successfully in the write mode. If writing fails, the error will
+// be stored to the err field of the node.
+func sidecars(input chan *node) (output chan *node) {
+	if *_sidecar != "write" {
+		return input
+	}
+
+	output = make(chan *node)
+	go func() {
+		for n := range input {
+			if n.err == nil && n.isfile() && n.isregular() {
+				line := formatSum(n.sum, filepath.Base(n.path))
+				if err := ioutil.WriteFile(n.path+sidecarExt, []byte(line), 0644); err != nil {
+					n.err = errorf("write sidecar failed: %s", err)
+				}
+			}
+			output <- n
+		}
+		close(output)
+	}()
+	return output
+}
+
+// Escapes and unescapes filenames in checksum lines.
+var (
+	escaper   = strings.NewReplacer("\\", "\\\\", "\n", "\\n")
+	unescaper = strings.NewReplacer("\\\\", "\\", "\\n", "\n")
+)
+
+// formatSum() formats a digest and a filename into a checksum line which can be
+// parsed by parseSum(). Backslashes and newlines in the filename are escaped.
+func formatSum(sum []byte, name string) string {
+	if strings.ContainsAny(name, "\\\n") {
+		return sprintf("\\%s  %s\n", encodeDigest(sum), escaper.Replace(name))
+	}
+	return sprintf("%s  %s\n", encodeDigest(sum), name)
+}
+
+// parseSum() parses a line in the format outputted by md5sum, sha256sum and
+// this tool, like 'digest  filename', the digest is in the selected encoding.
+// A filename prefixed with '*' (binary mode) is also accepted, and the filename
+// can be omitted. If the line starts with a backslash, backslashes and newlines
+// in the filename are escaped.
+func parseSum(line string) ([]byte, string, error) {
+	line = strings.TrimRight(line, "\r\n")
+	escaped := strings.HasPrefix(line, "\\")
+	strs := strings.SplitN(strings.TrimPrefix(line, "\\"), " ", 2)
+
+	sum, err := decodeDigest(strs[0])
+	if err != nil || len(sum) != sumSize {
+		return nil, "", errorf("invalid checksum line '%s'", line)
+	}
+
+	var name string
+	if len(strs) == 2 {
+		name = strings.TrimPrefix(strings.TrimPrefix(strs[1], " "), "*")
+	}
+
+	if escaped {
+		name = unescaper.Replace(name)
+	}
+	return sum, name, nil
+}
@@ -0,0 +1,89 @@
+// sidecar_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"crypto/md5"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSidecar(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "sidecar")
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("Hello"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "b.txt"), []byte("World"), 0644)
+
+	creator, sumSize, sidecarExt = md5.New, md5.Size, ".md5"
+	defer func() { *_sidecar = "" }()
+
+	run := func(mode string) map[string]*node {
+		*_sidecar = mode
+		nodes := make(map[string]*node)
+		for n := range sidecars(queue(digester(make(trigger), walk(make(trigger), []string{dir})))) {
+			nodes[filepath.Base(n.path)] = n
+		}
+		return nodes
+	}
+
+	a := assert.New(t)
+	nodes := run("write")
+	a.Len(nodes, 3)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name+".md5"))
+		a.NoErrorf(err, "%s", name)
+		a.Equalf(sprintf("%x  %s\n", nodes[name].sum, name), string(data), "%s", name)
+	}
+
+	// Sidecar files written by the previous run are ignored.
+	a.Len(run("write"), 3)
+
+	ioutil.WriteFile(filepath.Join(dir, "b.txt"), []byte("World!"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "c.txt"), []byte("Foo"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "d.txt.md5"), []byte("Bar"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "e.txt"), []byte("Foo"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "e.txt.md5"), []byte("invalid"), 0644)
+
+	nodes = run("verify")
+	a.Len(nodes, 5)
+	a.Equal(filepath.Join(dir, "a.txt")+": OK", nodes["a.txt"].String())
+	a.Equal(filepath.Join(dir, "b.txt")+": FAILED", nodes["b.txt"].String())
+	a.Nil(nodes["c.txt"]) // No sidecar file.
+	a.True(os.IsNotExist(nodes["d.txt"].err))
+	a.Error(nodes["e.txt"].err)
+}
+
+func TestParseSum(t *testing.T) {
+	sumSize = 2
+	for _, env := range []struct {
+		line string
+		sum  []byte
+		name string
+		ok   bool
+	}{
+		{"", nil, "", false},
+		{"1234", []byte{0x12, 0x34}, "", true},
+		{"1234\n", []byte{0x12, 0x34}, "", true},
+		{"1234  foo.iso\r\n", []byte{0x12, 0x34}, "foo.iso", true},
+		{"1234 *foo.iso", []byte{0x12, 0x34}, "foo.iso", true},
+		{"1234  foo bar", []byte{0x12, 0x34}, "foo bar", true},
+		{"\\1234  foo\\nbar\\\\", []byte{0x12, 0x34}, "foo\nbar\\", true},
+		{"123456  foo.iso", nil, "", false},
+		{"12xx  foo.iso", nil, "", false},
+	} {
+		a := assert.New(t)
+		sum, name, err := parseSum(env.line)
+		a.Equalf(env.ok, err == nil, "%+v", env)
+		a.Equalf(env.sum, sum, "%+v", env)
+		a.Equalf(env.name, name, "%+v", env)
+	}
+}
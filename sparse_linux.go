@@ -0,0 +1,123 @@
+// sparse_linux.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build linux
+
+package main
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+const (
+	seekData = 3 // SEEK_DATA
+	seekHole = 4 // SEEK_HOLE
+)
+
+// sparseReader reads a sparse file. Data regions are read from the disk but
+// hole regions are filled with zeros directly, so the digest is identical to
+// reading the whole file.
+type sparseReader struct {
+	*os.File
+	size   int64 // Apparent size
+	offset int64 // Offset of the next read
+	end    int64 // End of the current region
+	hole   bool  // Reports whether the current region is a hole
+}
+
+// openSparse() wraps a sparse file to a sparseReader. If the file is not
+// sparse, returns an error. (For Linux)
+func openSparse(f *os.File) (io.ReadCloser, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if size, ok := allocated(fi); !ok || size >= fi.Size() {
+		return nil, errorf("not a sparse file")
+	}
+	return &sparseReader{File: f, size: fi.Size()}, nil
+}
+
+// Read() reads up to len(p) bytes from the file.
+func (s *sparseReader) Read(p []byte) (n int, err error) {
+	if s.offset >= s.size {
+		return 0, io.EOF
+	}
+
+	if s.offset >= s.end {
+		if err = s.locate(); err != nil {
+			return 0, err
+		}
+	}
+
+	if limit := s.end - s.offset; int64(len(p)) > limit {
+		p = p[:limit]
+	}
+
+	if s.hole {
+		for i := range p {
+			p[i] = 0
+		}
+		n = len(p)
+	} else if n, err = s.File.ReadAt(p, s.offset); err == io.EOF && n > 0 {
+		err = nil
+	}
+	s.offset += int64(n)
+	return n, err
+}
+
+// Seek() sets the offset for the next Read.
+func (s *sparseReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += s.offset
+	case io.SeekEnd:
+		offset += s.size
+	}
+
+	if offset < 0 {
+		return 0, errorf("negative offset")
+	}
+	s.offset, s.end = offset, offset // Locate the region again.
+	return offset, nil
+}
+
+// locate() finds the region where the offset is.
+func (s *sparseReader) locate() error {
+	fd := int(s.Fd())
+	data, err := syscall.Seek(fd, s.offset, seekData)
+	switch {
+	case err == syscall.ENXIO:
+		s.hole, s.end = true, s.size // No more data.
+	case err != nil:
+		return err
+	case data > s.offset:
+		s.hole, s.end = true, data
+	default:
+		if s.end, err = syscall.Seek(fd, s.offset, seekHole); err != nil {
+			return err
+		}
+		s.hole = false
+	}
+
+	if s.end > s.size {
+		s.end = s.size
+	}
+	return nil
+}
+
+// allocated() returns the number of bytes allocated on the disk for the file.
+// (For Linux)
+func allocated(fi os.FileInfo) (int64, bool) {
+	if st, _ := fi.Sys().(*syscall.Stat_t); st != nil {
+		return int64(st.Blocks) * 512, true
+	}
+	return 0, false
+}
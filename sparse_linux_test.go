@@ -0,0 +1,67 @@
+// sparse_linux_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build linux
+
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSparseReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sparse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, env := range []struct {
+		size int64
+		data map[int64]string // Offset -> Data
+	}{
+		{1 << 20, map[int64]string{}},
+		{1 << 20, map[int64]string{0: "Hello"}},
+		{1 << 20, map[int64]string{1<<20 - 5: "World"}},
+		{4 << 20, map[int64]string{1 << 20: "Hello", 3<<20 + 7: "World"}},
+	} {
+		f, err := ioutil.TempFile(dir, "sparse.*.tmp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Truncate(env.size)
+
+		content := make([]byte, env.size)
+		for offset, data := range env.data {
+			f.WriteAt([]byte(data), offset)
+			copy(content[offset:], data)
+		}
+
+		a := assert.New(t)
+		r, err := openSparse(f)
+		if err != nil {
+			f.Close()
+			t.Skipf("sparse files are unsupported: %s", err)
+		}
+
+		data, err := ioutil.ReadAll(r)
+		a.NoErrorf(err, "%+v", env)
+		a.Truef(bytes.Equal(content, data), "%+v", env)
+
+		offset := env.size / 3
+		r.(io.Seeker).Seek(offset, io.SeekStart)
+		data, err = ioutil.ReadAll(r)
+		a.NoErrorf(err, "%+v", env)
+		a.Truef(bytes.Equal(content[offset:], data), "%+v", env)
+		r.Close()
+	}
+}
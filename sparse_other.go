@@ -0,0 +1,27 @@
+// sparse_other.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build !linux
+
+package main
+
+import (
+	"io"
+	"os"
+)
+
+// openSparse() wraps a sparse file. SEEK_DATA/SEEK_HOLE are not used, so it
+// always returns an error. (For Other Systems)
+func openSparse(f *os.File) (io.ReadCloser, error) {
+	return nil, errorf("sparse files are unsupported")
+}
+
+// allocated() returns the number of bytes allocated on the disk for the file.
+// It's unknown. (For Other Systems)
+func allocated(fi os.FileInfo) (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,18 @@
+// status_signal.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// OS signals which trigger outputting a status snapshot. (For Unix-Like System)
+var statusSignals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,15 @@
+// status_signal_windows.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build windows
+
+package main
+
+import "os"
+
+// OS signals which trigger outputting a status snapshot. (For Windows)
+var statusSignals = []os.Signal{}
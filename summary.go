@@ -0,0 +1,172 @@
+// summary.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Number of the slowest files reported by the summary.
+const numSlowest = 5
+
+// Statistics of the current run. They're gathered from the walk and the
+// digester stages and outputted when the run has done.
+var report = &summary{start: time.Now(), errors: make(map[string]int)}
+
+// summary represents end-of-run statistics.
+type summary struct {
+	mu      sync.Mutex
+	start   time.Time
+	files   int            // Number of files hashed
+	resumed int            // Number of files whose digests are restored from the journal
+	linked  int            // Number of hard links sharing digests of their targets
+	dirs    int            // Number of directories visited
+	hidden  int            // Number of hidden files skipped
+	skipped int            // Number of other files skipped, like sidecars and manifests
+	errors  map[string]int // Number of errors by kind
+	slowest []timing       // The slowest files, sorted by duration
+}
+
+// Time cost of computing the digest of a file.
+type timing struct {
+	Path     string        `json:"path"`
+	Duration time.Duration `json:"duration_ns"`
+	Bytes    int64         `json:"bytes"`
+}
+
+// visit() increases the number of directories visited.
+func (s *summary) visit() {
+	s.mu.Lock()
+	s.dirs++
+	s.mu.Unlock()
+}
+
+// skip() increases the number of hidden files skipped.
+func (s *summary) skip() {
+	s.mu.Lock()
+	s.hidden++
+	s.mu.Unlock()
+}
+
+// ignore() increases the number of files skipped for reasons other than being
+// hidden, such as sidecar files, manifest files and git directories.
+func (s *summary) ignore() {
+	s.mu.Lock()
+	s.skipped++
+	s.mu.Unlock()
+}
+
+// add() adds the result of a node which has been processed by the digester.
+// The elapsed parameter is the time cost of computing its digest. Files which
+// aren't read are counted separately, so they don't affect the throughput.
+func (s *summary) add(n *node, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case n.err == errInterrupted:
+	case n.err != nil:
+		s.errors[errorKind(n.err)]++
+	case n.resumed:
+		s.resumed++
+	case n.link != nil:
+		s.linked++
+	case n.isregular():
+		s.files++
+		s.slowest = append(s.slowest, timing{n._path(), elapsed, n.size()})
+		sort.SliceStable(s.slowest, func(i, j int) bool {
+			return s.slowest[i].Duration > s.slowest[j].Duration
+		})
+		if len(s.slowest) > numSlowest {
+			s.slowest = s.slowest[:numSlowest]
+		}
+	}
+}
+
+// output() outputs the summary to the w io.Writer. The format parameter can
+// be 'text' or 'json'.
+func (s *summary) output(w io.Writer, format string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		elapsed = time.Since(s.start)
+		bytes   = atomic.LoadInt64(&meter.bytes)
+		speed   = float64(bytes) / 1e6 / elapsed.Seconds()
+		errors  = 0
+	)
+
+	for _, count := range s.errors {
+		errors += count
+	}
+
+	if format == "json" {
+		data, _ := json.Marshal(map[string]interface{}{
+			"files":               s.files,
+			"resumed":             s.resumed,
+			"linked":              s.linked,
+			"directories":         s.dirs,
+			"hidden_skipped":      s.hidden,
+			"skipped":             s.skipped,
+			"errors":              errors,
+			"errors_by_kind":      s.errors,
+			"bytes":               bytes,
+			"wall_time_ns":        elapsed,
+			"throughput_mb_per_s": speed,
+			"slowest":             s.slowest,
+		})
+		fprintf(w, "%s\n", data)
+		return
+	}
+
+	kinds := make([]string, 0, len(s.errors))
+	for kind, count := range s.errors {
+		kinds = append(kinds, sprintf("%s: %d", kind, count))
+	}
+	sort.Strings(kinds)
+
+	fprintf(w, "files hashed:        %d\n", s.files)
+	fprintf(w, "files resumed:       %d\n", s.resumed)
+	fprintf(w, "files linked:        %d\n", s.linked)
+	fprintf(w, "directories visited: %d\n", s.dirs)
+	fprintf(w, "hidden skipped:      %d\n", s.hidden)
+	fprintf(w, "other skipped:       %d\n", s.skipped)
+	fprintf(w, "errors:              %d", errors)
+	if len(kinds) > 0 {
+		fprintf(w, " (%s)", strings.Join(kinds, ", "))
+	}
+	fprintf(w, "\n")
+	fprintf(w, "total bytes:         %d (%s)\n", bytes, humanize(bytes))
+	fprintf(w, "wall time:           %s\n", elapsed.Round(time.Millisecond))
+	fprintf(w, "throughput:          %.1f MB/s\n", speed)
+	if len(s.slowest) > 0 {
+		fprintf(w, "slowest files:\n")
+		for _, t := range s.slowest {
+			fprintf(w, "    %-12s %s\n", t.Duration.Round(time.Millisecond), t.Path)
+		}
+	}
+}
+
+// errorKind() classifies an error.
+func errorKind(err error) string {
+	switch {
+	case os.IsNotExist(err):
+		return "not-exist"
+	case os.IsPermission(err):
+		return "permission"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,67 @@
+// summary_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummary(t *testing.T) {
+	s := &summary{start: time.Now(), errors: make(map[string]int)}
+	s.visit()
+	s.skip()
+	s.skip()
+	s.ignore()
+	for i, n := range []*node{
+		&node{path: "a", sum: []byte{0x01}},
+		&node{path: "b", sum: []byte{0x02}},
+		&node{path: "c", err: os.ErrNotExist},
+		&node{path: "d", err: os.ErrPermission},
+		&node{path: "e", err: errorf("something wrong!")},
+		&node{path: "f", err: errInterrupted},
+		&node{path: "g", sum: []byte{0x03}},
+		&node{path: "h", sum: []byte{0x04}},
+		&node{path: "i", sum: []byte{0x05}},
+		&node{path: "j", sum: []byte{0x06}},
+		&node{path: "k", sum: []byte{0x07}, resumed: true},
+		&node{path: "l", sum: []byte{0x06}, link: &node{path: "j"}},
+	} {
+		s.add(n, time.Duration(i)*time.Millisecond)
+	}
+
+	a := assert.New(t)
+	a.Equal(6, s.files)
+	a.Equal(1, s.resumed)
+	a.Equal(1, s.linked)
+	a.Equal(map[string]int{"not-exist": 1, "permission": 1, "other": 1}, s.errors)
+	a.Len(s.slowest, numSlowest)
+	a.Equal("j", s.slowest[0].Path) // Resumed files and hard links aren't timed.
+	a.Equal("b", s.slowest[numSlowest-1].Path)
+
+	text := &bytes.Buffer{}
+	s.output(text, "text")
+	a.Contains(text.String(), "errors:              3 (not-exist: 1, other: 1, permission: 1)\n")
+	a.True(strings.HasPrefix(text.String(), "files hashed:        6\nfiles resumed:       1\nfiles linked:        1\ndirectories visited: 1\nhidden skipped:      2\nother skipped:       1\n"))
+
+	data := &bytes.Buffer{}
+	s.output(data, "json")
+	result := make(map[string]interface{})
+	a.NoError(json.Unmarshal(data.Bytes(), &result))
+	a.Equal(float64(6), result["files"])
+	a.Equal(float64(1), result["resumed"])
+	a.Equal(float64(1), result["linked"])
+	a.Equal(float64(3), result["errors"])
+	a.Equal(float64(2), result["hidden_skipped"])
+	a.Equal(float64(1), result["skipped"])
+}
@@ -0,0 +1,72 @@
+// throttle.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Token buckets shared by all digester goroutines. The bandwidth bucket limits
+// the number of bytes read per second and the iops bucket limits the number of
+// read operations per second. They're nil when there is no limit.
+var bandwidth, iops *bucket
+
+// bucket is a token bucket which is refilled at a constant rate. Its capacity
+// equals to the rate, which means the burst lasts one second at most.
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens per second
+	tokens float64 // Available tokens, it can be negative
+	last   time.Time
+}
+
+// init() inits a bucket instance by using its rate and returns itself.
+// The bucket is full after initialization.
+func (b *bucket) init(rate float64) *bucket {
+	b.rate, b.tokens, b.last = rate, rate, time.Now()
+	return b
+}
+
+// take() takes n tokens from the bucket. If there're not enough tokens, it
+// will wait until the debt is paid off. The waiting will end early if the
+// 'exit' parameter triggers.
+func (b *bucket) take(exit trigger, n int) {
+	if b == nil || n <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	if b.tokens += now.Sub(b.last).Seconds() * b.rate; b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+	b.tokens -= float64(n)
+	wait := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-exit:
+		}
+	}
+}
+
+// dropCache() advises the kernel that the data of the file in the range
+// [offset, offset+length) won't be accessed again in the near future, so
+// scans don't evict hot pages from the page cache. It only takes effect
+// when the nocache option is specified.
+func dropCache(r interface{}, offset, length int64) {
+	if f, ok := r.(interface{ Fd() uintptr }); ok && *_nocache {
+		fadvise(f.Fd(), offset, length)
+	}
+}
@@ -0,0 +1,28 @@
+// throttle_linux.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build linux
+
+package main
+
+import "syscall"
+
+const (
+	ioprioWhoProcess = 1 // IOPRIO_WHO_PROCESS
+	ioprioClassIdle  = 3 // IOPRIO_CLASS_IDLE
+	ioprioClassShift = 13
+)
+
+// ionice() sets the I/O scheduling class of the process to idle, so it only
+// gets disk time when no other program has asked for disk I/O. (For Linux)
+func ionice() error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, 0, ioprioClassIdle<<ioprioClassShift)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+// throttle_other.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build !linux
+
+package main
+
+// ionice() sets the I/O scheduling class of the process. (For Other Systems)
+func ionice() error {
+	return errorf("ionice is only supported on Linux")
+}
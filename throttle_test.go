@@ -0,0 +1,54 @@
+// throttle_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBucketTake(t *testing.T) {
+	for _, env := range []struct {
+		rate    float64
+		takes   []int
+		elapsed time.Duration // Minimal elapsed time.
+	}{
+		{100, []int{}, 0},
+		{100, []int{0, -1, 100}, 0},
+		{100, []int{50, 50, 10}, 100 * time.Millisecond},
+		{1000, []int{1000, 100, 100}, 200 * time.Millisecond},
+	} {
+		var (
+			b     = (&bucket{}).init(env.rate)
+			start = time.Now()
+		)
+
+		for _, n := range env.takes {
+			b.take(make(trigger), n)
+		}
+
+		elapsed := time.Since(start)
+		a := assert.New(t)
+		a.Truef(elapsed >= env.elapsed, "%+v %s", env, elapsed)
+		a.Truef(elapsed < env.elapsed+100*time.Millisecond, "%+v %s", env, elapsed)
+	}
+
+	// The waiting ends early when the exit trigger is closed.
+	var (
+		b     = (&bucket{}).init(1)
+		exit  = make(trigger)
+		start = time.Now()
+	)
+	close(exit)
+	b.take(exit, 100)
+	assert.True(t, time.Since(start) < time.Second)
+
+	// A nil bucket means no limit.
+	(*bucket)(nil).take(exit, 100)
+}
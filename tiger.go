@@ -0,0 +1,247 @@
+// tiger.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"hash"
+)
+
+// Parameters of the Tiger hash and the Tiger tree hash (TTH), whose leaves are
+// 1024-byte segments of the data as Direct Connect and Gnutella clients use.
+const (
+	tigerSize      = 24
+	tigerBlockSize = 64
+	tthLeafSize    = 1024
+)
+
+// tigerTable is the four S-boxes of Tiger, each one has 256 entries.
+var tigerTable = tigerSBoxes()
+
+// tigerSBoxes() generates S-boxes of Tiger like the reference implementation
+// does. Bytes of entries are shuffled in 5 passes, and the positions are taken
+// from the state of compressing the seed string with the S-boxes being built.
+func tigerSBoxes() *[4 * 256]uint64 {
+	var (
+		t     = new([4 * 256]uint64)
+		state = [3]uint64{0x0123456789abcdef, 0xfedcba9876543210, 0xf096a5b4c3b2e187}
+		seed  [8]uint64
+		abc   = 2
+	)
+
+	for i := range t {
+		t[i] = uint64(i&0xff) * 0x0101010101010101
+	}
+	decodeWords(seed[:], []byte("Tiger - A Fast New Hash Function, by Ross Anderson and Eli Biham"))
+
+	for pass := 0; pass < 5; pass++ {
+		for i := 0; i < 256; i++ {
+			for sb := 0; sb < len(t); sb += 256 {
+				if abc++; abc == 3 {
+					abc = 0
+					tigerCompress(t, &state, &seed)
+				}
+
+				// Swaps the col-th byte of two entries in the S-box.
+				for col := uint(0); col < 64; col += 8 {
+					var (
+						j    = sb + int(state[abc]>>col&0xff)
+						mask = uint64(0xff) << col
+						x, y = t[sb+i] & mask, t[j] & mask
+					)
+					t[sb+i] = t[sb+i]&^mask | y
+					t[j] = t[j]&^mask | x
+				}
+			}
+		}
+	}
+	return t
+}
+
+// tiger computes Tiger digests, whose bytes are in the little-endian order of
+// the reference implementation.
+type tiger struct {
+	state  [3]uint64
+	buf    [tigerBlockSize]byte
+	nbuf   int
+	length uint64
+}
+
+// newTiger() creates a hash.Hash instance computing Tiger digests.
+func newTiger() hash.Hash {
+	t := &tiger{}
+	t.Reset()
+	return t
+}
+
+// Write() writes data to the hash.
+func (t *tiger) Write(p []byte) (int, error) {
+	size := len(p)
+	t.length += uint64(size)
+	for len(p) > 0 {
+		n := copy(t.buf[t.nbuf:], p)
+		t.nbuf, p = t.nbuf+n, p[n:]
+		if t.nbuf == tigerBlockSize {
+			t.block(t.buf[:])
+			t.nbuf = 0
+		}
+	}
+	return size, nil
+}
+
+// Sum() appends the digest to b and returns the resulting slice. It doesn't
+// change the underlying state.
+func (t *tiger) Sum(b []byte) []byte {
+	c := *t
+	c.Write(padding(0x01, t.length))
+	for _, s := range c.state {
+		b = appendWord(b, s)
+	}
+	return b
+}
+
+// Reset() resets the hash to its initial state.
+func (t *tiger) Reset() {
+	*t = tiger{state: [3]uint64{0x0123456789abcdef, 0xfedcba9876543210, 0xf096a5b4c3b2e187}}
+}
+
+// Size() returns the number of bytes Sum() will return.
+func (t *tiger) Size() int {
+	return tigerSize
+}
+
+// BlockSize() returns the block size of Tiger.
+func (t *tiger) BlockSize() int {
+	return tigerBlockSize
+}
+
+// block() compresses a 64-byte block into the state.
+func (t *tiger) block(p []byte) {
+	var x [8]uint64
+	decodeWords(x[:], p)
+	tigerCompress(tigerTable, &t.state, &x)
+}
+
+// tigerCompress() compresses eight words into the state with S-boxes.
+func tigerCompress(t *[4 * 256]uint64, state *[3]uint64, words *[8]uint64) {
+	var (
+		a, b, c = state[0], state[1], state[2]
+		x       = *words
+	)
+
+	round := func(a, b, c *uint64, x, mul uint64) {
+		*c ^= x
+		v := *c
+		*a -= t[v&0xff] ^ t[256+v>>16&0xff] ^ t[512+v>>32&0xff] ^ t[768+v>>48&0xff]
+		*b += t[768+v>>8&0xff] ^ t[512+v>>24&0xff] ^ t[256+v>>40&0xff] ^ t[v>>56]
+		*b *= mul
+	}
+
+	pass := func(a, b, c *uint64, mul uint64) {
+		round(a, b, c, x[0], mul)
+		round(b, c, a, x[1], mul)
+		round(c, a, b, x[2], mul)
+		round(a, b, c, x[3], mul)
+		round(b, c, a, x[4], mul)
+		round(c, a, b, x[5], mul)
+		round(a, b, c, x[6], mul)
+		round(b, c, a, x[7], mul)
+	}
+
+	schedule := func() {
+		x[0] -= x[7] ^ 0xa5a5a5a5a5a5a5a5
+		x[1] ^= x[0]
+		x[2] += x[1]
+		x[3] -= x[2] ^ (^x[1] << 19)
+		x[4] ^= x[3]
+		x[5] += x[4]
+		x[6] -= x[5] ^ (^x[4] >> 23)
+		x[7] ^= x[6]
+		x[0] += x[7]
+		x[1] -= x[0] ^ (^x[7] << 19)
+		x[2] ^= x[1]
+		x[3] += x[2]
+		x[4] -= x[3] ^ (^x[2] >> 23)
+		x[5] ^= x[4]
+		x[6] += x[5]
+		x[7] -= x[6] ^ 0x0123456789abcdef
+	}
+
+	pass(&a, &b, &c, 5)
+	schedule()
+	pass(&c, &a, &b, 7)
+	schedule()
+	pass(&b, &c, &a, 9)
+
+	state[0], state[1], state[2] = a^state[0], b-state[1], c+state[2]
+}
+
+// newTTH() creates a hash.Hash instance computing Tiger tree hashes, which
+// follow the THEX specification. Each leaf is the Tiger digest of the segment
+// prefixed with 0x00, and each parent is the Tiger digest of its concatenated
+// children prefixed with 0x01.
+func newTTH() hash.Hash {
+	t := &treeHash{
+		size: tthLeafSize,
+		leaf: func() hash.Hash { return newPrefixed(newTiger(), 0x00) },
+		parent: func(left, right []byte) []byte {
+			h := newPrefixed(newTiger(), 0x01)
+			h.Write(left)
+			h.Write(right)
+			return h.Sum(nil)
+		},
+	}
+	t.Reset()
+	return t
+}
+
+// prefixed is a hash.Hash whose data is always prefixed with some bytes.
+type prefixed struct {
+	hash.Hash
+	prefix []byte
+}
+
+// newPrefixed() creates a hash.Hash instance which writes the prefix before data.
+func newPrefixed(h hash.Hash, prefix ...byte) hash.Hash {
+	p := &prefixed{h, prefix}
+	p.Reset()
+	return p
+}
+
+// Reset() resets the hash to the state which the prefix has been written.
+func (p *prefixed) Reset() {
+	p.Hash.Reset()
+	p.Hash.Write(p.prefix)
+}
+
+// padding() returns the padding of MD4-like hashes, which begins with the mark
+// byte and ends with the little-endian number of bits of the data.
+func padding(mark byte, length uint64) []byte {
+	pad := make([]byte, 1, 72)
+	pad[0] = mark
+	for (length+uint64(len(pad)))%64 != 56 {
+		pad = append(pad, 0)
+	}
+	return appendWord(pad, length<<3)
+}
+
+// decodeWords() decodes little-endian 64-bit words from p.
+func decodeWords(words []uint64, p []byte) {
+	for i := range words {
+		for j := 7; j >= 0; j-- {
+			words[i] = words[i]<<8 | uint64(p[i*8+j])
+		}
+	}
+}
+
+// appendWord() appends the little-endian form of the 64-bit word to b.
+func appendWord(b []byte, w uint64) []byte {
+	for i := uint(0); i < 64; i += 8 {
+		b = append(b, byte(w>>i))
+	}
+	return b
+}
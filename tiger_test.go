@@ -0,0 +1,90 @@
+// tiger_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestTiger(t *testing.T) {
+	for _, env := range []struct {
+		data   string
+		result string
+	}{
+		{"", "3293ac630c13f0245f92bbb1766e16167a4e58492dde73f3"},
+		{"abc", "2aab1484e8c158f2bfb8c5ff41b57a525129131c957b5f93"},
+		{"Tiger", "dd00230799f5009fec6debc838bb6a27df2b9d6f110c7937"},
+		{"message digest", "d981f8cb78201a950dcf3048751e441c517fca1aa55a29f6"},
+		{"abcdbcdecdefdefgefghfghighijhijkijkljklmklmnlmnomnopnopq", "0f7bf9a19b9c58f2b7610df7e84f0ac3a71c631e7b53f78e"},
+		{strings.Repeat("1234567890", 8), "1c14795529fd9f207a958f84c52f11e887fa0cabdfd91bfd"},
+	} {
+		a := assert.New(t)
+		h := newTiger()
+		for p := env.data; len(p) > 0; p = p[len(p)/2+1:] {
+			h.Write([]byte(p[:len(p)/2+1]))
+		}
+		a.Equalf(env.result, hex.EncodeToString(h.Sum(nil)), "%+v", env)
+		a.Equalf(env.result, hex.EncodeToString(h.Sum(nil)), "%+v", env)
+	}
+}
+
+func TestTTH(t *testing.T) {
+	for _, env := range []struct {
+		data   string
+		result string
+	}{
+		{"", "LWPNACQDBZRYXW3VHJVCJ64QBZNGHOHHHZWCLNQ"},
+		{"abc", "ASD4UJSEH5M47PDYB46KBTSQTSGDKLBHYXOMUIA"},
+	} {
+		h := newTTH()
+		h.Write([]byte(env.data))
+		assert.Equalf(t, env.result, encodings["base32"].encode(h.Sum(nil)), "%+v", env)
+	}
+
+	hash := func(prefix byte, data ...[]byte) []byte {
+		h := newTiger()
+		h.Write([]byte{prefix})
+		h.Write(bytes.Join(data, nil))
+		return h.Sum(nil)
+	}
+
+	for _, size := range []int{1, tthLeafSize, tthLeafSize + 1, 3 * tthLeafSize, 5*tthLeafSize + 7, 8 * tthLeafSize} {
+		a := assert.New(t)
+		data := bytes.Repeat([]byte{'x'}, size)
+
+		// Combines nodes level by level.
+		var level [][]byte
+		for i := 0; i < len(data); i += tthLeafSize {
+			j := i + tthLeafSize
+			if j > len(data) {
+				j = len(data)
+			}
+			level = append(level, hash(0x00, data[i:j]))
+		}
+		for len(level) > 1 {
+			var next [][]byte
+			for i := 0; i < len(level); i += 2 {
+				if i+1 < len(level) {
+					next = append(next, hash(0x01, level[i], level[i+1]))
+				} else {
+					next = append(next, level[i])
+				}
+			}
+			level = next
+		}
+
+		h := newTTH()
+		h.Write(data)
+		a.Equalf(level[0], h.Sum(nil), "%d", size)
+		a.Equalf(tigerSize, h.Size(), "%d", size)
+	}
+}
@@ -0,0 +1,730 @@
+// torrent.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The size of blocks which are leaves of merkle trees in BitTorrent v2.
+const btBlockSize = 16 << 10
+
+// The metainfo specified by the torrent option. It's nil when the option is not
+// specified.
+var meta *metainfo
+
+// The piece length of created torrents specified by the piece_length option.
+var pieceLength int64 = 256 << 10
+
+// metainfo contains files and piece hashes of a torrent.
+type metainfo struct {
+	name        string
+	pieceLength int64
+	pieces      []byte        // SHA-1 digests of pieces in BitTorrent v1.
+	files       []torrentFile // Padding files are included in BitTorrent v1.
+	single      bool          // Reports whether the torrent contains a single file.
+	v2          bool          // Reports whether the torrent supports BitTorrent v2.
+}
+
+// A torrentFile is a file in a torrent.
+type torrentFile struct {
+	path   []string // Path components relative to the torrent directory.
+	length int64
+	root   []byte // The pieces root in BitTorrent v2.
+	pad    bool   // Reports whether it's a padding file.
+}
+
+// loadTorrent() loads the metainfo file. Files in BitTorrent v2 are preferred if
+// the torrent is hybrid, because they can be verified independently.
+func loadTorrent(name string) (*metainfo, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := bdecode(data)
+	if err != nil {
+		return nil, errorf("parse %s failed: %s", filepath.Base(name), err)
+	}
+
+	var (
+		top, _  = v.(map[string]interface{})
+		info, _ = top["info"].(map[string]interface{})
+		m       = &metainfo{}
+		invalid = errorf("invalid metainfo %s", filepath.Base(name))
+	)
+
+	m.name, _ = info["name"].(string)
+	m.pieceLength, _ = info["piece length"].(int64)
+	if info == nil || !validName(m.name) || m.pieceLength <= 0 {
+		return nil, invalid
+	}
+
+	if version, _ := info["meta version"].(int64); version == 2 {
+		tree, _ := info["file tree"].(map[string]interface{})
+		if m.v2 = true; tree == nil || m.tree(tree, nil) != nil || len(m.files) == 0 {
+			return nil, invalid
+		} else if m.pieceLength < btBlockSize || m.pieceLength&(m.pieceLength-1) != 0 {
+			return nil, invalid
+		}
+		m.single = len(m.files) == 1 && len(m.files[0].path) == 1 && m.files[0].path[0] == m.name
+		return m, nil
+	}
+
+	if files, ok := info["files"].([]interface{}); ok {
+		for _, f := range files {
+			file, _ := f.(map[string]interface{})
+			length, ok := file["length"].(int64)
+			path, _ := file["path"].([]interface{})
+			if !ok || length < 0 || len(path) == 0 {
+				return nil, invalid
+			}
+
+			tf := torrentFile{length: length}
+			for _, p := range path {
+				if str, _ := p.(string); validName(str) {
+					tf.path = append(tf.path, str)
+				} else {
+					return nil, invalid
+				}
+			}
+			attr, _ := file["attr"].(string)
+			tf.pad = strings.Contains(attr, "p")
+			m.files = append(m.files, tf)
+		}
+	} else if length, ok := info["length"].(int64); ok && length >= 0 {
+		m.single, m.files = true, []torrentFile{{path: []string{m.name}, length: length}}
+	}
+
+	var total int64
+	for _, f := range m.files {
+		total += f.length
+	}
+
+	pieces, _ := info["pieces"].(string)
+	if len(m.files) == 0 || int64(len(pieces)) != (total+m.pieceLength-1)/m.pieceLength*sha1.Size {
+		return nil, invalid
+	}
+	m.pieces = []byte(pieces)
+	return m, nil
+}
+
+// tree() appends files in the file tree of BitTorrent v2 to the metainfo. Files
+// are sorted by their paths.
+func (m *metainfo) tree(dir map[string]interface{}, path []string) error {
+	names := make([]string, 0, len(dir))
+	for name := range dir {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		entry, _ := dir[name].(map[string]interface{})
+		if entry == nil || !validName(name) {
+			return errorf("invalid file tree")
+		}
+
+		p := append(append([]string(nil), path...), name)
+		if file, ok := entry[""].(map[string]interface{}); ok {
+			length, ok := file["length"].(int64)
+			root, _ := file["pieces root"].(string)
+			if !ok || length < 0 || length > 0 && len(root) != sha256.Size {
+				return errorf("invalid file tree")
+			} else if length == 0 {
+				root = string(make([]byte, sha256.Size))
+			}
+			m.files = append(m.files, torrentFile{path: p, length: length, root: []byte(root)})
+		} else if err := m.tree(entry, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validName() checks whether the name is a valid path component in torrents.
+// Names which can escape from the torrent directory are invalid.
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\\x00")
+}
+
+// pieced() checks whether files are read by the torrents stage instead of the
+// digester, which is required by pieces spanning files in BitTorrent v1.
+func pieced() bool {
+	return *_torrent_create != "" || meta != nil && !meta.v2
+}
+
+// torrent() returns nodes of files in the torrent under the root directory in
+// reverse order, so they're walked in the order of the torrent. If the torrent
+// contains a single file, the root should be the file itself. Sizes of files
+// are checked, and the expected pieces root is stored to the want field in
+// BitTorrent v2.
+func (n *node) torrent() []*node {
+	if meta.single {
+		n.check(meta.files[0])
+		return nil
+	} else if !n.isdir() {
+		n.err = errorf("the torrent contains multiple files")
+		return nil
+	}
+
+	var ns []*node
+	for i := len(meta.files) - 1; i >= 0; i-- {
+		if f := meta.files[i]; !f.pad {
+			m := (&node{depth: n.depth + 1}).init(filepath.Join(append([]string{n.path}, f.path...)...))
+			m.check(f)
+			ns = append(ns, m)
+		}
+	}
+	return ns
+}
+
+// check() checks whether the node matches the file in the torrent.
+func (n *node) check(f torrentFile) {
+	switch {
+	case n.err != nil:
+	case !n.isregular():
+		n.err = errorf("%s is not a regular file", n.path)
+	case n.Size() != f.length:
+		n.err = errorf("size mismatch, expected %d", f.length)
+	case meta.v2:
+		n.want = f.root
+	}
+}
+
+// torrents() verifies files against pieces of the torrent in BitTorrent v1, or
+// creates a torrent if the torrent_create option is specified. Files in both
+// cases are read sequentially, because pieces span files. Files in BitTorrent
+// v2 are verified by the digester like other checksum files.
+func torrents(exit trigger, input chan *node) (output chan *node) {
+	if !pieced() {
+		return input
+	}
+
+	output = make(chan *node)
+	go func() {
+		if *_torrent_create != "" {
+			(&creation{}).init().run(exit, input, output)
+		} else {
+			(&verification{}).run(exit, input, output)
+		}
+		close(output)
+	}()
+	return output
+}
+
+// A heldNode is a node waiting for the verification of its last piece.
+type heldNode struct {
+	*node
+	first, last int // Indexes of pieces overlapping the file.
+}
+
+// verification verifies files under a root against pieces of the torrent.
+type verification struct {
+	output      chan *node
+	piece       blocks
+	i           int          // The number of complete pieces.
+	bad         map[int]bool // Indexes of bad pieces.
+	held        []*heldNode
+	next        int   // The index of the next file in the torrent.
+	offset      int64 // The offset of the next file in the torrent.
+	interrupted bool
+}
+
+// run() verifies files from the input channel and outputs them. A file is
+// outputted after all pieces overlapping it have been verified, then bad
+// pieces are stored to its err field.
+func (v *verification) run(exit trigger, input, output chan *node) {
+	v.output = output
+	for n := range input {
+		switch {
+		case n.depth == 0:
+			v.finish()
+			v.piece.reset(int(meta.pieceLength), sha1.New())
+			v.i, v.bad, v.next, v.offset = 0, make(map[int]bool), 0, 0
+			if meta.single && n.err == nil {
+				v.file(exit, n)
+			} else {
+				output <- n
+			}
+		case n.isdir():
+			output <- n
+		default:
+			v.file(exit, n)
+		}
+	}
+	v.finish()
+}
+
+// file() reads the file and writes its data to pieces. Padding files before it
+// are filled with zeros.
+func (v *verification) file(exit trigger, n *node) {
+	for v.next < len(meta.files) && meta.files[v.next].pad {
+		zeros(meta.files[v.next].length, v.write)
+		v.next++
+	}
+
+	if v.next >= len(meta.files) {
+		n.err = errorf("not in the torrent")
+		v.output <- n
+		return
+	}
+
+	var (
+		f     = meta.files[v.next]
+		first = int(v.offset / meta.pieceLength)
+		last  = int((v.offset + f.length - 1) / meta.pieceLength)
+	)
+	if f.length == 0 {
+		last = first - 1
+	}
+	v.next, v.offset = v.next+1, v.offset+f.length
+	v.held = append(v.held, &heldNode{n, first, last})
+
+	var size int64
+	switch {
+	case v.interrupted:
+		n.err = errInterrupted
+	case n.err == nil:
+		size, n.err = n.feed(exit, f.length, v.write)
+		v.interrupted = n.err == errInterrupted
+	}
+	meter.done()
+
+	// Pieces must be aligned even if the file can't be read.
+	zeros(f.length-size, v.write)
+	v.release()
+}
+
+// write() writes data to pieces and verifies complete pieces.
+func (v *verification) write(p []byte) {
+	v.piece.write(p, v.done)
+}
+
+// done() verifies the piece.
+func (v *verification) done(sum []byte) {
+	i := v.i * sha1.Size
+	v.bad[v.i] = i+sha1.Size > len(meta.pieces) || !bytes.Equal(sum, meta.pieces[i:i+sha1.Size])
+	v.i++
+}
+
+// release() outputs held nodes whose pieces have been verified.
+func (v *verification) release() {
+	for len(v.held) > 0 && v.held[0].last < v.i {
+		h := v.held[0]
+		v.held = v.held[1:]
+
+		var bad []int
+		for i := h.first; i <= h.last; i++ {
+			if v.bad[i] {
+				bad = append(bad, i)
+			}
+		}
+
+		switch {
+		case h.err != nil:
+		case v.interrupted:
+			h.err = errInterrupted
+		case len(bad) > 0:
+			h.err = errorf("bad pieces %s of %d", ranges(bad), len(meta.pieces)/sha1.Size)
+		default:
+			h.want = meta.pieces[h.first*sha1.Size : (h.last+1)*sha1.Size]
+		}
+		v.output <- h.node
+	}
+}
+
+// finish() verifies the last piece and outputs all held nodes.
+func (v *verification) finish() {
+	if v.bad == nil {
+		return
+	}
+
+	for ; v.next < len(meta.files) && meta.files[v.next].pad; v.next++ {
+		zeros(meta.files[v.next].length, v.write)
+	}
+
+	if v.piece.n > 0 && !v.interrupted {
+		v.done(v.piece.last())
+	}
+	v.i = len(meta.pieces) / sha1.Size
+	v.release()
+}
+
+// creation creates a torrent from files under a root.
+type creation struct {
+	root   *node
+	err    error
+	files  []interface{}          // The files list in BitTorrent v1.
+	tree   map[string]interface{} // The file tree in BitTorrent v2.
+	layers map[string]interface{} // Piece layers in BitTorrent v2.
+	piece  blocks
+	pieces []byte
+	offset int64 // The size of all files and padding files.
+	v1, v2 bool
+}
+
+// init() initializes the creation by the torrent_version option.
+func (c *creation) init() *creation {
+	c.tree, c.layers = make(map[string]interface{}), make(map[string]interface{})
+	c.piece.reset(int(pieceLength), sha1.New())
+	c.v1 = *_torrent_version == "v1" || *_torrent_version == "hybrid"
+	c.v2 = *_torrent_version == "v2" || *_torrent_version == "hybrid"
+	return c
+}
+
+// run() reads files from the input channel and writes the torrent, then outputs
+// the root node whose digest is the info hash of the torrent.
+func (c *creation) run(exit trigger, input, output chan *node) {
+	for n := range input {
+		switch {
+		case n.depth == 0:
+			if c.root = n; n.err == nil && n.isregular() {
+				c.file(exit, n)
+			}
+		case c.err != nil || n.isdir():
+		default:
+			c.file(exit, n)
+		}
+	}
+
+	if c.root == nil {
+		return
+	}
+
+	n := &node{FileInfo: c.root.FileInfo, path: c.root.path, i: c.root.i, err: c.root.err}
+	if n.err == nil {
+		n.err = c.err
+	}
+	if n.err == nil {
+		n.sum, n.err = c.write()
+	}
+	output <- n
+}
+
+// file() reads the file and adds it to the torrent.
+func (c *creation) file(exit trigger, n *node) {
+	switch {
+	case n.err != nil:
+		c.err = n.err
+		return
+	case !n.isregular():
+		c.err = errorf("%s is not a regular file", n.path)
+		return
+	}
+
+	// Files are aligned to pieces by padding files in hybrid torrents.
+	if rest := c.offset % pieceLength; c.v1 && c.v2 && rest > 0 {
+		pad := pieceLength - rest
+		c.files = append(c.files, map[string]interface{}{
+			"attr":   "p",
+			"length": pad,
+			"path":   []interface{}{".pad", sprintf("%d", pad)},
+		})
+		zeros(pad, c.write1)
+		c.offset += pad
+	}
+
+	m := &merkle{level: pieceLevel()}
+	m.Reset()
+
+	size, err := n.feed(exit, n.Size(), func(p []byte) {
+		if c.v1 {
+			c.write1(p)
+		}
+		if c.v2 {
+			m.Write(p)
+		}
+	})
+	meter.done()
+	if err != nil {
+		c.err = err
+		return
+	}
+	c.offset += size
+
+	var path []interface{}
+	if n.depth == 0 {
+		path = []interface{}{c.name()}
+	} else {
+		rel, _ := filepath.Rel(c.root.path, n.path)
+		for _, name := range strings.Split(filepath.ToSlash(rel), "/") {
+			path = append(path, name)
+		}
+	}
+	c.files = append(c.files, map[string]interface{}{"length": size, "path": path})
+
+	// Files in the file tree are dicts whose keys are empty strings.
+	dir := c.tree
+	for _, name := range path[:len(path)-1] {
+		if dir[name.(string)] == nil {
+			dir[name.(string)] = make(map[string]interface{})
+		}
+		dir = dir[name.(string)].(map[string]interface{})
+	}
+
+	file := map[string]interface{}{"length": size}
+	if size > 0 {
+		root, layer := m.finish()
+		file["pieces root"] = root
+		if size > pieceLength {
+			c.layers[string(root)] = layer
+		}
+	}
+	dir[path[len(path)-1].(string)] = map[string]interface{}{"": file}
+}
+
+// write1() writes data to pieces in BitTorrent v1.
+func (c *creation) write1(p []byte) {
+	c.piece.write(p, func(sum []byte) { c.pieces = append(c.pieces, sum...) })
+}
+
+// name() returns the name of the torrent, which is the base name of the root.
+func (c *creation) name() string {
+	if abs, err := filepath.Abs(c.root.path); err == nil {
+		return filepath.Base(abs)
+	}
+	return filepath.Base(c.root.path)
+}
+
+// write() writes the torrent and returns its info hash, which is the SHA-1 digest
+// of the bencoded info dict in BitTorrent v1 and hybrid torrents, or the SHA-256
+// digest in BitTorrent v2 torrents.
+func (c *creation) write() ([]byte, error) {
+	if len(c.files) == 0 {
+		return nil, errorf("no files in the torrent")
+	}
+
+	info := map[string]interface{}{"name": c.name(), "piece length": pieceLength}
+	if c.v1 {
+		if c.piece.n > 0 {
+			c.pieces = append(c.pieces, c.piece.last()...)
+		}
+
+		info["pieces"] = c.pieces
+		if c.root.isregular() {
+			info["length"] = c.offset
+		} else {
+			info["files"] = c.files
+		}
+	}
+
+	torrent := map[string]interface{}{"info": info, "created by": "go-hash/" + binary}
+	if c.v2 {
+		info["meta version"], info["file tree"] = 2, c.tree
+		torrent["piece layers"] = c.layers
+	}
+
+	buf := &bytes.Buffer{}
+	bencode(buf, info)
+	h := sha256.New()
+	if c.v1 {
+		h = sha1.New()
+	}
+	h.Write(buf.Bytes())
+
+	buf.Reset()
+	bencode(buf, torrent)
+	if err := ioutil.WriteFile(*_torrent_create, buf.Bytes(), 0644); err != nil {
+		return nil, errorf("write torrent failed: %s", err)
+	}
+	return h.Sum(nil), nil
+}
+
+// feed() reads at most length bytes from the file and passes them to the write
+// function, returns the number of bytes read. Reading will be interrupted if the
+// 'exit' parameter triggers.
+func (n *node) feed(exit trigger, length int64, write func(p []byte)) (int64, error) {
+	r, err := n.open()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	var (
+		buf   = make([]byte, bufferSize)
+		total int64
+		nr    int
+	)
+
+	for total < length {
+		select {
+		case <-exit:
+			return total, errInterrupted
+		default:
+		}
+
+		if rest := length - total; rest < int64(len(buf)) {
+			buf = buf[:rest]
+		}
+
+		iops.take(exit, 1)
+		nr, err = r.Read(buf)
+		bandwidth.take(exit, nr)
+
+		write(buf[:nr])
+		meter.read(nr)
+		total += int64(nr)
+
+		if err == io.EOF && total < length {
+			return total, io.ErrUnexpectedEOF
+		} else if err != nil && err != io.EOF {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// zeros() passes size zero bytes to the write function.
+func zeros(size int64, write func(p []byte)) {
+	buf := make([]byte, bufferSize)
+	for ; size > 0; size -= int64(len(buf)) {
+		if size < int64(len(buf)) {
+			buf = buf[:size]
+		}
+		write(buf)
+	}
+}
+
+// ranges() formats sorted indexes like '1-3, 5'.
+func ranges(indexes []int) string {
+	var strs []string
+	for i := 0; i < len(indexes); {
+		j := i
+		for j+1 < len(indexes) && indexes[j+1] == indexes[j]+1 {
+			j++
+		}
+
+		if i == j {
+			strs = append(strs, sprintf("%d", indexes[i]))
+		} else {
+			strs = append(strs, sprintf("%d-%d", indexes[i], indexes[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(strs, ", ")
+}
+
+// pieceLevel() returns the level of pieces in merkle trees of BitTorrent v2,
+// leaves are on the level zero.
+func pieceLevel() int {
+	level := 0
+	for size := int64(btBlockSize); size < pieceLength; size <<= 1 {
+		level++
+	}
+	return level
+}
+
+// A merkleNode is the root of a complete binary subtree of a merkle tree.
+type merkleNode struct {
+	sum   []byte
+	level int
+	data  bool // Reports whether the subtree contains data of the file.
+}
+
+// merkle computes the pieces root of a file in BitTorrent v2, which is the root
+// of the merkle tree whose leaves are SHA-256 digests of 16 KiB blocks. Leaves
+// are padded with zeros to a power of two. Roots of subtrees on the level of
+// pieces are recorded as the piece layer if the level isn't negative.
+type merkle struct {
+	blocks
+	stack []merkleNode
+	level int
+	layer []byte
+}
+
+// newMerkle() creates a hash.Hash instance computing pieces roots.
+func newMerkle() hash.Hash {
+	m := &merkle{level: -1}
+	m.Reset()
+	return m
+}
+
+// Write() writes data to the hash.
+func (m *merkle) Write(p []byte) (int, error) {
+	m.write(p, func(sum []byte) {
+		m.stack, m.layer = m.push(m.stack, m.layer, merkleNode{sum, 0, true})
+	})
+	return len(p), nil
+}
+
+// Sum() appends the pieces root to b and returns the resulting slice. It doesn't
+// change the underlying state. The pieces root of an empty file is zero.
+func (m *merkle) Sum(b []byte) []byte {
+	root, _ := m.finish()
+	return append(b, root...)
+}
+
+// Reset() resets the hash to its initial state.
+func (m *merkle) Reset() {
+	m.reset(btBlockSize, sha256.New())
+	m.stack, m.layer = nil, nil
+}
+
+// Size() returns the number of bytes Sum() will return.
+func (m *merkle) Size() int {
+	return sha256.Size
+}
+
+// BlockSize() returns the block size of sha256.
+func (m *merkle) BlockSize() int {
+	return sha256.BlockSize
+}
+
+// finish() pads the merkle tree and returns the pieces root and the piece layer.
+// It doesn't change the underlying state.
+func (m *merkle) finish() ([]byte, []byte) {
+	if m.n == 0 {
+		return make([]byte, sha256.Size), nil
+	}
+
+	var (
+		stack = append([]merkleNode(nil), m.stack...)
+		layer = append([]byte(nil), m.layer...)
+	)
+
+	stack, layer = m.push(stack, layer, merkleNode{m.last(), 0, true})
+	for len(stack) > 1 {
+		top := stack[len(stack)-1]
+		stack, layer = m.push(stack, layer, merkleNode{zeroHash(top.level), top.level, false})
+	}
+	return stack[0].sum, layer
+}
+
+// push() pushes the node to the stack, and merges subtrees on the same level.
+func (m *merkle) push(stack []merkleNode, layer []byte, node merkleNode) ([]merkleNode, []byte) {
+	for {
+		if node.data && node.level == m.level {
+			layer = append(layer, node.sum...)
+		}
+
+		if len(stack) == 0 || stack[len(stack)-1].level != node.level {
+			return append(stack, node), layer
+		}
+
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		node = merkleNode{parent(top.sum, node.sum), node.level + 1, top.data || node.data}
+	}
+}
+
+// zeroHash() returns the root of a merkle tree whose leaves are zero on the level.
+func zeroHash(level int) []byte {
+	sum := make([]byte, sha256.Size)
+	for i := 0; i < level; i++ {
+		sum = parent(sum, sum)
+	}
+	return sum
+}
@@ -0,0 +1,179 @@
+// torrent_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMerkle(t *testing.T) {
+	defer func() { pieceLength = 256 << 10 }()
+	pieceLength = 2 * btBlockSize
+
+	// Computes the merkle tree level by level.
+	tree := func(data []byte) ([]byte, []byte) {
+		level := chunks(data, btBlockSize)
+		for len(level)&(len(level)-1) != 0 {
+			level = append(level, make([]byte, sha256.Size))
+		}
+
+		var layer []byte
+		for i := 0; len(level) > 1; i++ {
+			var next [][]byte
+			for j := 0; j < len(level); j += 2 {
+				next = append(next, parent(level[j], level[j+1]))
+			}
+			if i == 0 {
+				for j, sum := range next {
+					if int64(j)*pieceLength < int64(len(data)) {
+						layer = append(layer, sum...)
+					}
+				}
+			}
+			level = next
+		}
+		return level[0], layer
+	}
+
+	for _, size := range []int{1, btBlockSize, btBlockSize + 1, 3 * btBlockSize, 5*btBlockSize + 1, 8 * btBlockSize} {
+		a := assert.New(t)
+		data := bytes.Repeat([]byte{'x'}, size)
+		root, layer := tree(data)
+
+		m := &merkle{level: pieceLevel()}
+		m.Reset()
+		m.Write(data)
+		sum, l := m.finish()
+		a.Equalf(root, sum, "%d", size)
+		if int64(size) > pieceLength {
+			a.Equalf(layer, l, "%d", size)
+		}
+
+		h := newMerkle()
+		h.Write(data)
+		a.Equalf(root, h.Sum(nil), "%d", size)
+	}
+	assert.Equal(t, make([]byte, sha256.Size), newMerkle().Sum(nil))
+}
+
+func TestTorrent(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "torrent")
+	defer os.RemoveAll(dir)
+
+	data := filepath.Join(dir, "data")
+	os.MkdirAll(filepath.Join(data, "sub"), 0755)
+	ioutil.WriteFile(filepath.Join(data, "a"), bytes.Repeat([]byte{'a'}, 40000), 0644)
+	ioutil.WriteFile(filepath.Join(data, "empty"), nil, 0644)
+	ioutil.WriteFile(filepath.Join(data, "sub", "b"), bytes.Repeat([]byte{'b'}, 1000), 0644)
+	ioutil.WriteFile(filepath.Join(data, "sub", "c"), bytes.Repeat([]byte{'c'}, 30000), 0644)
+
+	defer func() {
+		*_torrent_create, *_torrent_version, *_depth, *_all = "", "v1", 1, false
+		meta, pieceLength = nil, 256<<10
+	}()
+	pieceLength = btBlockSize
+
+	run := func(root string) map[string]*node {
+		nodes := make(map[string]*node)
+		for n := range torrents(make(trigger), queue(digester(make(trigger), walk(make(trigger), []string{root})))) {
+			rel, _ := filepath.Rel(dir, n.path)
+			nodes[filepath.ToSlash(rel)] = n
+		}
+		return nodes
+	}
+
+	for _, version := range []string{"v1", "v2", "hybrid"} {
+		a := assert.New(t)
+		name := filepath.Join(dir, version+".torrent")
+
+		*_torrent_create, *_torrent_version, *_depth = name, version, math.MaxInt32
+		creator = factories["md5"]
+		nodes := run(data)
+		if a.Lenf(nodes, 1, version) && a.NotNilf(nodes["data"], version) {
+			a.NoErrorf(nodes["data"].err, version)
+		}
+
+		var err error
+		*_torrent_create, *_depth, *_all = "", 1, true
+		meta, err = loadTorrent(name)
+		if !a.NoErrorf(err, version) {
+			continue
+		}
+		a.Equalf(version != "v1", meta.v2, version)
+		creator = newMerkle
+
+		nodes = run(data)
+		for _, file := range []string{"data/a", "data/empty", "data/sub/b", "data/sub/c"} {
+			if a.NotNilf(nodes[file], "%s %s", version, file) {
+				a.NoErrorf(nodes[file].err, "%s %s", version, file)
+				a.NotNilf(nodes[file].want, "%s %s", version, file)
+			}
+		}
+
+		// Corrupts the first byte of 'sub/c', the last piece of 'a' and
+		// the piece of 'sub/b' are also bad in v1.
+		ioutil.WriteFile(filepath.Join(data, "sub", "c"), append([]byte{'x'}, bytes.Repeat([]byte{'c'}, 29999)...), 0644)
+		nodes = run(data)
+		ioutil.WriteFile(filepath.Join(data, "sub", "c"), bytes.Repeat([]byte{'c'}, 30000), 0644)
+
+		if version == "v1" {
+			a.EqualError(nodes["data/a"].err, "bad pieces 2 of 5")
+			a.EqualError(nodes["data/sub/b"].err, "bad pieces 2 of 5")
+			a.EqualError(nodes["data/sub/c"].err, "bad pieces 2 of 5")
+		} else {
+			a.NoErrorf(nodes["data/a"].err, version)
+			a.NoErrorf(nodes["data/sub/b"].err, version)
+			a.Equalf(errMismatch, nodes["data/sub/c"].err, version)
+		}
+		a.NoErrorf(nodes["data/empty"].err, version)
+		meta = nil
+	}
+}
+
+func TestLoadTorrent(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "torrent")
+	defer os.RemoveAll(dir)
+
+	for _, env := range []struct {
+		info map[string]interface{}
+		ok   bool
+	}{
+		{map[string]interface{}{"name": "a", "piece length": 4, "length": 5, "pieces": string(make([]byte, 40))}, true},
+		{map[string]interface{}{"name": "a", "piece length": 4, "length": 5, "pieces": string(make([]byte, 20))}, false},
+		{map[string]interface{}{"name": "..", "piece length": 4, "length": 5, "pieces": string(make([]byte, 40))}, false},
+		{map[string]interface{}{"name": "a", "piece length": 4, "pieces": "", "files": []interface{}{
+			map[string]interface{}{"length": 0, "path": []interface{}{"..", "etc"}},
+		}}, false},
+		{map[string]interface{}{"name": "a", "piece length": 16384, "meta version": 2, "file tree": map[string]interface{}{
+			"b": map[string]interface{}{"": map[string]interface{}{"length": 0}},
+		}}, true},
+		{map[string]interface{}{"name": "a", "piece length": 16384, "meta version": 2, "file tree": map[string]interface{}{
+			"b": map[string]interface{}{"": map[string]interface{}{"length": 1}},
+		}}, false},
+	} {
+		buf := &bytes.Buffer{}
+		bencode(buf, map[string]interface{}{"info": env.info})
+		name := filepath.Join(dir, "x.torrent")
+		ioutil.WriteFile(name, buf.Bytes(), 0644)
+
+		_, err := loadTorrent(name)
+		assert.Equalf(t, env.ok, err == nil, "%+v", env)
+	}
+}
+
+func TestRanges(t *testing.T) {
+	assert.Equal(t, "", ranges(nil))
+	assert.Equal(t, "1-3, 5, 7-8", ranges([]int{1, 2, 3, 5, 7, 8}))
+}
@@ -0,0 +1,16 @@
+// uring_linux.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build linux,!mips,!mipsle,!mips64,!mips64le
+
+package main
+
+// Numbers of io_uring system calls, which are unified on most architectures.
+const (
+	sysIOURingSetup = 425
+	sysIOURingEnter = 426
+)
@@ -0,0 +1,16 @@
+// uring_linux_mips64x.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build linux,mips64 linux,mips64le
+
+package main
+
+// Numbers of io_uring system calls, which are offset by 5000 in the N64 ABI.
+const (
+	sysIOURingSetup = 5425
+	sysIOURingEnter = 5426
+)
@@ -0,0 +1,16 @@
+// uring_linux_mipsx.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build linux,mips linux,mipsle
+
+package main
+
+// Numbers of io_uring system calls, which are offset by 4000 in the O32 ABI.
+const (
+	sysIOURingSetup = 4425
+	sysIOURingEnter = 4426
+)
@@ -0,0 +1,121 @@
+// xattr.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+package main
+
+import (
+	"encoding/hex"
+	"math"
+	"strconv"
+)
+
+// Prefix of extended attributes, which follows the shatag convention. The digest
+// is stored in 'user.shatag.<algo>' and the mtime is stored in 'user.shatag.ts'.
+// The size is stored in 'user.shatag.size' additionally.
+const xattrPrefix = "user.shatag."
+
+// The name of the extended attribute storing digests, like 'user.shatag.sha256'.
+var xattrName string
+
+// The error of a node whose extended attributes were written before it was
+// modified last time.
+var errOutdated = errorf("outdated digest in xattrs")
+
+// tag() reads the digest stored in extended attributes of the file. It returns
+// nil if the digest doesn't exist. If the size or the mtime of the file has
+// changed since the digest was stored, the fresh result will be false.
+func (n *node) tag() (sum []byte, fresh bool, err error) {
+	value, err := getxattr(n.path, xattrName)
+	if err != nil || value == nil {
+		return nil, false, err
+	}
+
+	if sum, err = hex.DecodeString(string(value)); err != nil || len(sum) != sumSize {
+		return nil, false, errorf("invalid digest '%s' in xattrs", value)
+	}
+
+	if value, err = getxattr(n.path, xattrPrefix+"ts"); err != nil || value == nil {
+		return sum, false, err
+	}
+
+	// The shatag tool stores the mtime as a float, so only compares it in
+	// microsecond precision.
+	ts, err := strconv.ParseFloat(string(value), 64)
+	if err != nil {
+		return nil, false, errorf("invalid timestamp '%s' in xattrs", value)
+	}
+	mtime := float64(n.ModTime().UnixNano()) / 1e9
+	fresh = math.Abs(ts-mtime) < 1e-6
+
+	if value, err = getxattr(n.path, xattrPrefix+"size"); err != nil {
+		return sum, false, err
+	} else if value != nil {
+		fresh = fresh && string(value) == strconv.FormatInt(n.Size(), 10)
+	}
+	return sum, fresh, nil
+}
+
+// settag() stores the digest, the mtime and the size of the file to extended
+// attributes.
+func (n *node) settag() error {
+	mtime := n.ModTime()
+	for _, attr := range [][2]string{
+		{xattrName, hex.EncodeToString(n.sum)},
+		{xattrPrefix + "ts", sprintf("%d.%09d", mtime.Unix(), mtime.Nanosecond())},
+		{xattrPrefix + "size", strconv.FormatInt(n.Size(), 10)},
+	} {
+		if err := setxattr(n.path, attr[0], []byte(attr[1])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// xattr() handles a file for the xattr option when walking. In the verify
+// mode, the expected digest of a file is loaded from its extended attributes,
+// and files without the digest will be ignored. In the cache mode, the digest
+// of a file is reused if the file hasn't changed since it was stored. It
+// returns nil when the file should be ignored.
+func (n *node) xattr() *node {
+	sum, fresh, err := n.tag()
+	switch {
+	case *_xattr == "verify" && err != nil:
+		n.err = err
+	case *_xattr == "verify" && sum == nil:
+		return nil
+	case *_xattr == "verify" && !fresh:
+		n.err = errOutdated
+	case *_xattr == "verify":
+		n.want = sum
+	case *_xattr == "cache" && fresh:
+		n.sum, n.tagged = sum, true
+	}
+	return n
+}
+
+// tags() stores digests of files to their extended attributes in the write mode
+// and the cache mode. Digests loaded from extended attributes won't be stored
+// again. If storing fails, the error will be stored to the err field of the node.
+func tags(input chan *node) (output chan *node) {
+	if *_xattr != "write" && *_xattr != "cache" {
+		return input
+	}
+
+	output = make(chan *node)
+	go func() {
+		for n := range input {
+			if n.err == nil && n.isfile() && n.isregular() && !n.tagged {
+				if err := n.settag(); err != nil {
+					n.err = errorf("write xattrs failed: %s", err)
+				}
+			}
+			output <- n
+		}
+		close(output)
+	}()
+	return output
+}
@@ -0,0 +1,36 @@
+// xattr_linux.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build linux
+
+package main
+
+import "syscall"
+
+// getxattr() returns the value of the extended attribute of the file. It returns
+// nil if the attribute doesn't exist. (For Linux)
+func getxattr(path, name string) ([]byte, error) {
+	buf := make([]byte, 256)
+	for {
+		n, err := syscall.Getxattr(path, name, buf)
+		switch {
+		case err == syscall.ENODATA:
+			return nil, nil
+		case err == syscall.ERANGE:
+			buf = make([]byte, 2*len(buf))
+		case err != nil:
+			return nil, err
+		default:
+			return buf[:n], nil
+		}
+	}
+}
+
+// setxattr() sets the value of the extended attribute of the file. (For Linux)
+func setxattr(path, name string, value []byte) error {
+	return syscall.Setxattr(path, name, value, 0)
+}
@@ -0,0 +1,87 @@
+// xattr_linux_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build linux
+
+package main
+
+import (
+	"crypto/md5"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestXattr(t *testing.T) {
+	dir, _ := ioutil.TempDir(".", "xattr")
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "a"), []byte("Hello"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "b"), []byte("World"), 0644)
+	if err := setxattr(filepath.Join(dir, "a"), "user.test", []byte("1")); err != nil {
+		t.Skipf("extended attributes are unsupported: %s", err)
+	}
+
+	creator, sumSize, xattrName = md5.New, md5.Size, xattrPrefix+"md5"
+	defer func() { *_xattr = "" }()
+
+	run := func(mode string) map[string]*node {
+		*_xattr = mode
+		nodes := make(map[string]*node)
+		for n := range tags(queue(digester(make(trigger), walk(make(trigger), []string{dir})))) {
+			nodes[filepath.Base(n.path)] = n
+		}
+		return nodes
+	}
+
+	a := assert.New(t)
+	nodes := run("write")
+	for _, name := range []string{"a", "b"} {
+		value, err := getxattr(filepath.Join(dir, name), xattrName)
+		a.NoErrorf(err, "%s", name)
+		a.Equalf(sprintf("%x", nodes[name].sum), string(value), "%s", name)
+
+		value, err = getxattr(filepath.Join(dir, name), xattrPrefix+"size")
+		a.NoErrorf(err, "%s", name)
+		a.Equalf("5", string(value), "%s", name)
+	}
+
+	// The content of 'b' is corrupted but its mtime isn't changed.
+	fi, _ := os.Stat(filepath.Join(dir, "b"))
+	ioutil.WriteFile(filepath.Join(dir, "b"), []byte("Worle"), 0644)
+	os.Chtimes(filepath.Join(dir, "b"), fi.ModTime(), fi.ModTime())
+	ioutil.WriteFile(filepath.Join(dir, "c"), []byte("Foo"), 0644)
+
+	nodes = run("verify")
+	a.Equal(filepath.Join(dir, "a")+": OK", nodes["a"].String())
+	a.Equal(filepath.Join(dir, "b")+": FAILED", nodes["b"].String())
+	a.Nil(nodes["c"]) // No digest in extended attributes.
+
+	// The digest is outdated after 'a' has been modified.
+	os.Chtimes(filepath.Join(dir, "a"), time.Now(), time.Now().Add(time.Hour))
+	nodes = run("verify")
+	a.Equal(errOutdated, nodes["a"].err)
+
+	// Only 'a' and 'c' are read in the cache mode.
+	before := meter.bytes
+	nodes = run("cache")
+	a.Equal(int64(5+3), meter.bytes-before)
+	a.False(nodes["a"].tagged)
+	a.True(nodes["b"].tagged)
+	a.False(nodes["c"].tagged)
+
+	sum := md5.Sum([]byte("World"))
+	a.Equal(sum[:], nodes["b"].sum)
+
+	// All digests are cached now.
+	before = meter.bytes
+	run("cache")
+	a.Equal(int64(0), meter.bytes-before)
+}
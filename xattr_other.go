@@ -0,0 +1,20 @@
+// xattr_other.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-18
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-18
+
+// +build !linux
+
+package main
+
+// getxattr() returns the value of the extended attribute of the file. (For Other Systems)
+func getxattr(path, name string) ([]byte, error) {
+	return nil, errorf("extended attributes are only supported on Linux")
+}
+
+// setxattr() sets the value of the extended attribute of the file. (For Other Systems)
+func setxattr(path, name string, value []byte) error {
+	return errorf("extended attributes are only supported on Linux")
+}